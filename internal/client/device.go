@@ -2,43 +2,152 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 type DeviceActions struct{ c *Client }
 
 func (c *Client) Device() *DeviceActions { return &DeviceActions{c: c} }
 
-func (d *DeviceActions) Info(ctx context.Context) (any, error) {
+// Device represents the pod state returned by /devices/{id}.
+type Device struct {
+	ID              string `json:"deviceId"`
+	OwnerID         string `json:"ownerId"`
+	Model           string `json:"modelString"`
+	FirmwareVersion string `json:"firmwareVersion"`
+	Online          bool   `json:"online"`
+	HasWater        bool   `json:"hasWater"`
+	NeedsPriming    bool   `json:"needsPriming"`
+	Priming         bool   `json:"priming"`
+	LastPrime       string `json:"lastPrime"`
+
+	LeftUserID             string `json:"leftUserId"`
+	LeftHeatingLevel       int    `json:"leftHeatingLevel"`
+	LeftTargetHeatingLevel int    `json:"leftTargetHeatingLevel"`
+	LeftNowHeating         bool   `json:"leftNowHeating"`
+	LeftHeatingDuration    int    `json:"leftHeatingDuration"`
+
+	RightUserID             string `json:"rightUserId"`
+	RightHeatingLevel       int    `json:"rightHeatingLevel"`
+	RightTargetHeatingLevel int    `json:"rightTargetHeatingLevel"`
+	RightNowHeating         bool   `json:"rightNowHeating"`
+	RightHeatingDuration    int    `json:"rightHeatingDuration"`
+
+	// Kelvin state is only reported by newer pods; kept raw since the shape varies by firmware.
+	LeftKelvin  json.RawMessage `json:"leftKelvin,omitempty"`
+	RightKelvin json.RawMessage `json:"rightKelvin,omitempty"`
+
+	// Raw is the whole payload, so fields not modeled above (water level
+	// details, sensor info) are still available.
+	Raw json.RawMessage `json:"-"`
+}
+
+// Generation parses the pod generation from the model string ("Pod 4 Ultra" -> 4).
+// Returns 0 when the model is unknown.
+func (d *Device) Generation() int {
+	model := strings.ToLower(d.Model)
+	idx := strings.Index(model, "pod")
+	if idx == -1 {
+		return 0
+	}
+	rest := strings.TrimLeftFunc(model[idx+len("pod"):], func(r rune) bool { return !unicode.IsDigit(r) })
+	end := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsDigit(r) })
+	if end != -1 {
+		rest = rest[:end]
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// KelvinBased reports whether the pod uses the newer kelvin temperature control,
+// which ignores level changes sent to the legacy /users/{id}/temperature route.
+func (d *Device) KelvinBased() bool {
+	if hasJSONValue(d.LeftKelvin) || hasJSONValue(d.RightKelvin) {
+		return true
+	}
+	return d.Generation() >= 3
+}
+
+func hasJSONValue(raw json.RawMessage) bool {
+	return len(raw) > 0 && string(raw) != "null"
+}
+
+// Info fetches the current device state.
+func (d *DeviceActions) Info(ctx context.Context) (*Device, error) {
 	id, err := d.c.EnsureDeviceID(ctx)
 	if err != nil {
 		return nil, err
 	}
 	path := fmt.Sprintf("/devices/%s", id)
-	var res any
-	err = d.c.do(ctx, http.MethodGet, path, nil, nil, &res)
-	return res, err
+	var res map[string]json.RawMessage
+	if err := d.c.do(ctx, http.MethodGet, path, nil, nil, &res); err != nil {
+		return nil, err
+	}
+	var dev Device
+	if err := decodeField(path, res, "result", &dev); err != nil {
+		return nil, err
+	}
+	dev.Raw = res["result"]
+	if dev.ID == "" {
+		dev.ID = id
+	}
+	return &dev, nil
 }
 
-func (d *DeviceActions) Peripherals(ctx context.Context) (any, error) {
+// Peripherals lists the accessories connected to the pod. Entries are
+// returned as decoded JSON since their shape is not documented.
+func (d *DeviceActions) Peripherals(ctx context.Context) ([]any, error) {
 	id, err := d.c.EnsureDeviceID(ctx)
 	if err != nil {
 		return nil, err
 	}
 	path := fmt.Sprintf("/devices/%s/peripherals", id)
-	var res any
-	err = d.c.do(ctx, http.MethodGet, path, nil, nil, &res)
-	return res, err
+	var res map[string]json.RawMessage
+	if err := d.c.do(ctx, http.MethodGet, path, nil, nil, &res); err != nil {
+		return nil, err
+	}
+	peripherals := []any{}
+	if err := decodeField(path, res, "peripherals", &peripherals); err != nil {
+		return nil, err
+	}
+	return peripherals, nil
 }
 
-func (d *DeviceActions) Online(ctx context.Context) (any, error) {
+// Online reports whether the pod is connected to the cloud.
+func (d *DeviceActions) Online(ctx context.Context) (bool, error) {
 	id, err := d.c.EnsureDeviceID(ctx)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 	path := fmt.Sprintf("/devices/%s/online", id)
-	var res any
-	err = d.c.do(ctx, http.MethodGet, path, nil, nil, &res)
-	return res, err
+	var res map[string]json.RawMessage
+	if err := d.c.do(ctx, http.MethodGet, path, nil, nil, &res); err != nil {
+		return false, err
+	}
+	var online bool
+	if err := decodeField(path, res, "online", &online); err != nil {
+		return false, err
+	}
+	return online, nil
+}
+
+// decodeField unmarshals payload[key] into out. A missing key is an error, so
+// an unexpected response shape is reported instead of read as empty or false.
+func decodeField(path string, payload map[string]json.RawMessage, key string, out any) error {
+	raw, ok := payload[key]
+	if !ok || !hasJSONValue(raw) {
+		return fmt.Errorf("%s: response has no %q field", path, key)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("%s: decode %q: %w", path, key, err)
+	}
+	return nil
 }
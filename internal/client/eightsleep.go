@@ -43,8 +43,28 @@ type Client struct {
 	// MaxRetries is the number of retry attempts for transient errors.
 	// A value of 0 means no retries; 1 means one retry, etc.
 	MaxRetries int
-	token      string
-	tokenExp   time.Time
+	// Observer, when set, is told about failed responses, retries and token
+	// refreshes, e.g. to export them as metrics.
+	Observer Observer
+	token    string
+	tokenExp time.Time
+}
+
+// Observer receives client events. Implementations must be safe for
+// concurrent use.
+type Observer interface {
+	// APIError reports a failed API response; status is 0 for network errors.
+	APIError(status int)
+	// Retry reports that a request is about to be retried.
+	Retry()
+	// TokenRefresh reports a new token obtained from the auth server.
+	TokenRefresh()
+}
+
+func (c *Client) observe(fn func(Observer)) {
+	if c.Observer != nil {
+		fn(c.Observer)
+	}
 }
 
 // New creates a Client.
@@ -95,9 +115,14 @@ func (c *Client) Authenticate(ctx context.Context) error {
 	// Authenticate with server
 	log.Debug("authenticating with server")
 	if err := c.authTokenEndpoint(ctx); err == nil {
+		c.observe(Observer.TokenRefresh)
 		return nil
 	}
-	return c.authLegacyLogin(ctx)
+	if err := c.authLegacyLogin(ctx); err != nil {
+		return err
+	}
+	c.observe(Observer.TokenRefresh)
+	return nil
 }
 
 // EnsureUserID populates UserID by calling /users/me if missing.
@@ -333,7 +358,9 @@ func (c *Client) doWithBase(ctx context.Context, baseURL, method, path string, q
 			if ctx.Err() != nil {
 				return ctx.Err()
 			}
+			c.observe(func(o Observer) { o.APIError(0) })
 			if attempt < maxRetries {
+				c.observe(Observer.Retry)
 				if err := sleepWithContext(ctx, backoffDelay(attempt)); err != nil {
 					return err
 				}
@@ -342,9 +369,14 @@ func (c *Client) doWithBase(ctx context.Context, baseURL, method, path string, q
 			return err
 		}
 
+		if resp.StatusCode >= 300 {
+			c.observe(func(o Observer) { o.APIError(resp.StatusCode) })
+		}
+
 		if resp.StatusCode == http.StatusTooManyRequests {
 			_ = resp.Body.Close()
 			if attempt < maxRetries {
+				c.observe(Observer.Retry)
 				if err := sleepWithContext(ctx, backoffDelay(attempt)); err != nil {
 					return err
 				}
@@ -360,6 +392,7 @@ func (c *Client) doWithBase(ctx context.Context, baseURL, method, path string, q
 			c.token = ""
 			_ = tokencache.Clear(c.Identity())
 			if attempt < maxRetries {
+				c.observe(Observer.Retry)
 				if err := c.ensureToken(ctx); err != nil {
 					return err
 				}
@@ -372,6 +405,7 @@ func (c *Client) doWithBase(ctx context.Context, baseURL, method, path string, q
 			b, _ := io.ReadAll(resp.Body)
 			_ = resp.Body.Close()
 			if attempt < maxRetries {
+				c.observe(Observer.Retry)
 				if err := sleepWithContext(ctx, backoffDelay(attempt)); err != nil {
 					return err
 				}
@@ -427,11 +461,7 @@ func (c *Client) TurnOn(ctx context.Context) error {
 	if err := c.requireUser(ctx); err != nil {
 		return err
 	}
-	path := fmt.Sprintf("/users/%s/temperature", c.UserID)
-	body := map[string]any{
-		"currentState": map[string]string{"type": "smart"},
-	}
-	return c.do(ctx, http.MethodPut, path, nil, body, nil)
+	return c.setUserState(ctx, c.UserID, "smart")
 }
 
 // TurnOff powers device off by setting state to "off".
@@ -439,9 +469,13 @@ func (c *Client) TurnOff(ctx context.Context) error {
 	if err := c.requireUser(ctx); err != nil {
 		return err
 	}
-	path := fmt.Sprintf("/users/%s/temperature", c.UserID)
+	return c.setUserState(ctx, c.UserID, "off")
+}
+
+func (c *Client) setUserState(ctx context.Context, userID, state string) error {
+	path := fmt.Sprintf("/users/%s/temperature", userID)
 	body := map[string]any{
-		"currentState": map[string]string{"type": "off"},
+		"currentState": map[string]string{"type": state},
 	}
 	return c.do(ctx, http.MethodPut, path, nil, body, nil)
 }
@@ -450,24 +484,32 @@ func (c *Client) TurnOff(ctx context.Context) error {
 // level: -100 (max cool) to +100 (max heat)
 // duration: seconds, 0 = indefinite
 func (c *Client) SetTemperatureDevice(ctx context.Context, level int, duration int) error {
-	deviceID, err := c.EnsureDeviceID(ctx)
+	// Determine which side this user controls
+	side, err := c.UserSide(ctx)
 	if err != nil {
 		return err
 	}
-	if err := c.requireUser(ctx); err != nil {
+	return c.SetTemperatureDeviceSide(ctx, side, level, duration)
+}
+
+// SetTemperatureDeviceSide sets target heating level via device endpoint for the given side(s).
+func (c *Client) SetTemperatureDeviceSide(ctx context.Context, side Side, level int, duration int) error {
+	if _, err := ParseSide(string(side)); err != nil {
 		return err
 	}
 	if level < -100 || level > 100 {
 		return fmt.Errorf("level must be between -100 and 100")
 	}
-
-	// Determine which side this user controls
-	side := c.getUserSide(ctx)
+	deviceID, err := c.EnsureDeviceID(ctx)
+	if err != nil {
+		return err
+	}
 	path := fmt.Sprintf("/devices/%s", deviceID)
 
 	// Set level first (duration can fail when schedule is active)
-	levelBody := map[string]int{
-		side + "TargetHeatingLevel": level,
+	levelBody := map[string]int{}
+	for _, s := range side.Sides() {
+		levelBody[string(s)+"TargetHeatingLevel"] = level
 	}
 	if err := c.do(ctx, http.MethodPut, path, nil, levelBody, nil); err != nil {
 		return err
@@ -475,8 +517,9 @@ func (c *Client) SetTemperatureDevice(ctx context.Context, level int, duration i
 
 	// Set duration if specified
 	if duration > 0 {
-		durationBody := map[string]int{
-			side + "HeatingDuration": duration,
+		durationBody := map[string]int{}
+		for _, s := range side.Sides() {
+			durationBody[string(s)+"HeatingDuration"] = duration
 		}
 		// Duration can fail silently when schedule active, ignore error
 		_ = c.do(ctx, http.MethodPut, path, nil, durationBody, nil)
@@ -484,28 +527,6 @@ func (c *Client) SetTemperatureDevice(ctx context.Context, level int, duration i
 	return nil
 }
 
-// getUserSide determines if user controls left or right side of the bed
-func (c *Client) getUserSide(ctx context.Context) string {
-	deviceID, err := c.EnsureDeviceID(ctx)
-	if err != nil {
-		return "left" // default
-	}
-	path := fmt.Sprintf("/devices/%s", deviceID)
-	var res struct {
-		Result struct {
-			LeftUserID  string `json:"leftUserId"`
-			RightUserID string `json:"rightUserId"`
-		} `json:"result"`
-	}
-	if err := c.do(ctx, http.MethodGet, path, nil, nil, &res); err != nil {
-		return "left"
-	}
-	if res.Result.RightUserID == c.UserID {
-		return "right"
-	}
-	return "left"
-}
-
 func (c *Client) Identity() tokencache.Identity {
 	return tokencache.Identity{
 		BaseURL:  c.BaseURL,
@@ -519,10 +540,15 @@ func (c *Client) SetTemperature(ctx context.Context, level int) error {
 	if err := c.requireUser(ctx); err != nil {
 		return err
 	}
+	return c.setUserLevel(ctx, c.UserID, level)
+}
+
+// setUserLevel sets the level through the legacy user endpoint.
+func (c *Client) setUserLevel(ctx context.Context, userID string, level int) error {
 	if level < -100 || level > 100 {
 		return fmt.Errorf("level must be between -100 and 100")
 	}
-	path := fmt.Sprintf("/users/%s/temperature", c.UserID)
+	path := fmt.Sprintf("/users/%s/temperature", userID)
 	body := map[string]int{"currentLevel": level}
 	return c.do(ctx, http.MethodPut, path, nil, body, nil)
 }
@@ -540,7 +566,11 @@ func (c *Client) GetStatus(ctx context.Context) (*TempStatus, error) {
 	if err := c.requireUser(ctx); err != nil {
 		return nil, err
 	}
-	path := fmt.Sprintf("/users/%s/temperature", c.UserID)
+	return c.userStatus(ctx, c.UserID)
+}
+
+func (c *Client) userStatus(ctx context.Context, userID string) (*TempStatus, error) {
+	path := fmt.Sprintf("/users/%s/temperature", userID)
 	var res TempStatus
 	if err := c.do(ctx, http.MethodGet, path, nil, nil, &res); err != nil {
 		return nil, err
@@ -550,15 +580,16 @@ func (c *Client) GetStatus(ctx context.Context) (*TempStatus, error) {
 
 // SleepDay represents aggregated sleep metrics for a day.
 type SleepDay struct {
-	Date          string  `json:"day"`
-	Score         float64 `json:"score"`
-	Tnt           int     `json:"tnt"`
-	Respiratory   float64 `json:"respiratoryRate"`
-	HeartRate     float64 `json:"heartRate"`
-	LatencyAsleep float64 `json:"latencyAsleepSeconds"`
-	LatencyOut    float64 `json:"latencyOutSeconds"`
-	Duration      float64 `json:"sleepDurationSeconds"`
-	Stages        []Stage `json:"stages"`
+	Date          string         `json:"day"`
+	Score         float64        `json:"score"`
+	Tnt           int            `json:"tnt"`
+	Respiratory   float64        `json:"respiratoryRate"`
+	HeartRate     float64        `json:"heartRate"`
+	LatencyAsleep float64        `json:"latencyAsleepSeconds"`
+	LatencyOut    float64        `json:"latencyOutSeconds"`
+	Duration      float64        `json:"sleepDurationSeconds"`
+	Stages        []Stage        `json:"stages"`
+	Sessions      []SleepSession `json:"sessions"`
 	SleepQuality  struct {
 		HRV struct {
 			Score float64 `json:"score"`
@@ -575,6 +606,77 @@ type Stage struct {
 	Duration float64 `json:"duration"`
 }
 
+// SleepSession is one in-bed session within a sleep day.
+type SleepSession struct {
+	ID         string `json:"id"`
+	Timeseries struct {
+		TempBedC  []TimeseriesPoint `json:"tempBedC"`
+		TempRoomC []TimeseriesPoint `json:"tempRoomC"`
+	} `json:"timeseries"`
+}
+
+// TimeseriesPoint is a ["timestamp", value] pair as returned by the API.
+type TimeseriesPoint struct {
+	Time  string
+	Value float64
+}
+
+func (p *TimeseriesPoint) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 2 {
+		return fmt.Errorf("timeseries point: expected 2 elements, got %d", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &p.Time); err != nil {
+		return fmt.Errorf("timeseries point time: %w", err)
+	}
+	if err := json.Unmarshal(raw[1], &p.Value); err != nil {
+		return fmt.Errorf("timeseries point value: %w", err)
+	}
+	return nil
+}
+
+func (p TimeseriesPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{p.Time, p.Value})
+}
+
+// BedTempC averages bed temperature samples across sessions; ok is false when there are none.
+func (d *SleepDay) BedTempC() (avg float64, ok bool) {
+	return averageSamples(d.Sessions, func(s SleepSession) []TimeseriesPoint { return s.Timeseries.TempBedC })
+}
+
+// RoomTempC averages room temperature samples across sessions; ok is false when there are none.
+func (d *SleepDay) RoomTempC() (avg float64, ok bool) {
+	return averageSamples(d.Sessions, func(s SleepSession) []TimeseriesPoint { return s.Timeseries.TempRoomC })
+}
+
+// StageTotals sums stage durations in seconds by stage name; the API repeats a
+// stage each time the sleeper cycles back into it.
+func (d *SleepDay) StageTotals() map[string]float64 {
+	totals := map[string]float64{}
+	for _, s := range d.Stages {
+		totals[s.Stage] += s.Duration
+	}
+	return totals
+}
+
+func averageSamples(sessions []SleepSession, pick func(SleepSession) []TimeseriesPoint) (float64, bool) {
+	var sum float64
+	var n int
+	for _, s := range sessions {
+		for _, p := range pick(s) {
+			sum += p.Value
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
 // GetSleepDay fetches sleep trends for a date (YYYY-MM-DD).
 func (c *Client) GetSleepDay(ctx context.Context, date string, timezone string) (*SleepDay, error) {
 	if err := c.requireUser(ctx); err != nil {
@@ -597,11 +699,19 @@ func (c *Client) GetSleepDay(ctx context.Context, date string, timezone string)
 	if len(res.Days) == 0 {
 		return nil, fmt.Errorf("no sleep data for %s", date)
 	}
-	day := &res.Days[0]
-	if day.Stages == nil {
-		day.Stages = []Stage{}
+	day := res.Days[0].normalized()
+	return &day, nil
+}
+
+// normalized replaces nil slices so JSON output renders [] instead of null.
+func (d SleepDay) normalized() SleepDay {
+	if d.Stages == nil {
+		d.Stages = []Stage{}
+	}
+	if d.Sessions == nil {
+		d.Sessions = []SleepSession{}
 	}
-	return day, nil
+	return d
 }
 
 // ListTracks returns audio tracks metadata.
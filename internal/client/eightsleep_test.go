@@ -2,8 +2,14 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -31,6 +37,11 @@ func mockServer(t *testing.T) (*httptest.Server, *Client) {
 		http.NotFound(w, r)
 	})
 
+	mux.HandleFunc("/devices/dev-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"result":{"deviceId":"dev-1","leftUserId":"uid-123","rightUserId":"uid-456","leftTargetHeatingLevel":-40,"rightHeatingLevel":15,"hasWater":true}}`))
+	})
+
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
 		// first call rate limits, second succeeds
 		if r.Header.Get("X-Test-Retry") == "done" {
@@ -70,6 +81,236 @@ func TestRequireUserFilledAutomatically(t *testing.T) {
 	}
 }
 
+// fixtureClient serves each path's body, read from testdata when it names a
+// .json file, to a client bound to dev-1.
+func fixtureClient(t *testing.T, bodies map[string]string) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	for path, body := range bodies {
+		if strings.HasSuffix(body, ".json") {
+			data, err := os.ReadFile(filepath.Join("testdata", body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = string(data)
+		}
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.DeviceID = "dev-1"
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+	return c
+}
+
+func TestDeviceInfoDecodes(t *testing.T) {
+	c := fixtureClient(t, map[string]string{"/devices/dev-1": "device.json"})
+
+	dev, err := c.Device().Info(context.Background())
+	if err != nil {
+		t.Fatalf("device info: %v", err)
+	}
+	if dev.ID != "dev-1" || dev.RightUserID != "uid-456" || dev.FirmwareVersion != "2.3.21.0" {
+		t.Fatalf("unexpected device %+v", dev)
+	}
+	if dev.LeftTargetHeatingLevel != -40 || dev.RightHeatingLevel != 15 || dev.LeftHeatingDuration != 5400 || !dev.HasWater {
+		t.Fatalf("unexpected device levels %+v", dev)
+	}
+	// Fields the struct does not model survive in Raw.
+	var raw map[string]any
+	if err := json.Unmarshal(dev.Raw, &raw); err != nil {
+		t.Fatalf("raw payload: %v", err)
+	}
+	if raw["ledBrightnessLevel"] != float64(30) || raw["sensorInfo"] == nil {
+		t.Fatalf("raw payload lost fields: %v", raw)
+	}
+}
+
+func TestDevicePeripheralsAndOnline(t *testing.T) {
+	c := fixtureClient(t, map[string]string{
+		"/devices/dev-1/peripherals": "peripherals.json",
+		"/devices/dev-1/online":      "online.json",
+	})
+	peripherals, err := c.Device().Peripherals(context.Background())
+	if err != nil {
+		t.Fatalf("peripherals: %v", err)
+	}
+	if peripherals == nil || len(peripherals) != 0 {
+		t.Fatalf("unexpected peripherals %v", peripherals)
+	}
+	online, err := c.Device().Online(context.Background())
+	if err != nil {
+		t.Fatalf("online: %v", err)
+	}
+	if !online {
+		t.Fatal("expected pod online")
+	}
+
+	// Entries keep whatever shape the API sends.
+	c = fixtureClient(t, map[string]string{"/devices/dev-1/peripherals": `{"peripherals":[{"type":"base","online":true}]}`})
+	peripherals, err = c.Device().Peripherals(context.Background())
+	if err != nil {
+		t.Fatalf("peripherals: %v", err)
+	}
+	if p, ok := peripherals[0].(map[string]any); len(peripherals) != 1 || !ok || p["type"] != "base" {
+		t.Fatalf("unexpected peripherals %v", peripherals)
+	}
+}
+
+func TestDeviceRejectsUnexpectedShapes(t *testing.T) {
+	c := fixtureClient(t, map[string]string{
+		"/devices/dev-1":             `{"device":{"deviceId":"dev-1"}}`,
+		"/devices/dev-1/peripherals": `{"result":{"peripherals":[]}}`,
+		"/devices/dev-1/online":      `{"status":"online"}`,
+	})
+	ctx := context.Background()
+	if _, err := c.Device().Info(ctx); err == nil || !strings.Contains(err.Error(), `no "result" field`) {
+		t.Fatalf("info: expected missing result error, got %v", err)
+	}
+	if _, err := c.Device().Peripherals(ctx); err == nil || !strings.Contains(err.Error(), `no "peripherals" field`) {
+		t.Fatalf("peripherals: expected missing key error, got %v", err)
+	}
+	if _, err := c.Device().Online(ctx); err == nil || !strings.Contains(err.Error(), `no "online" field`) {
+		t.Fatalf("online: expected missing key error, got %v", err)
+	}
+}
+
+func TestSideResolution(t *testing.T) {
+	srv, c := mockServer(t)
+	defer srv.Close()
+
+	side, err := c.UserSide(context.Background())
+	if err != nil {
+		t.Fatalf("user side: %v", err)
+	}
+	if side != SideLeft {
+		t.Fatalf("expected left side, got %s", side)
+	}
+
+	c.UserID = "someone-else"
+	if _, err := c.UserSide(context.Background()); err == nil {
+		t.Fatal("expected error for user without a side, got nil")
+	}
+
+	if _, err := ParseSide("middle"); err == nil {
+		t.Fatal("expected error for invalid side, got nil")
+	}
+	users, err := c.sideUsers(context.Background(), SideBoth)
+	if err != nil {
+		t.Fatalf("side users: %v", err)
+	}
+	if len(users) != 2 || users[1].UserID != "uid-456" {
+		t.Fatalf("unexpected side users %+v", users)
+	}
+}
+
+func TestDeviceGeneration(t *testing.T) {
+	tests := []struct {
+		model  string
+		kelvin string
+		gen    int
+		isKelv bool
+	}{
+		{"Pod 4 Ultra", "", 4, true},
+		{"Pod 3", "", 3, true},
+		{"Pod 2 Pro", "", 2, false},
+		{"Pod Pro", "", 0, false},
+		{"", `{"active":true}`, 0, true},
+		{"", "null", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			d := Device{Model: tt.model}
+			if tt.kelvin != "" {
+				d.LeftKelvin = []byte(tt.kelvin)
+			}
+			if got := d.Generation(); got != tt.gen {
+				t.Errorf("Generation() = %d, want %d", got, tt.gen)
+			}
+			if got := d.KelvinBased(); got != tt.isKelv {
+				t.Errorf("KelvinBased() = %v, want %v", got, tt.isKelv)
+			}
+		})
+	}
+}
+
+func TestSetTemperatureViaAuto(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		device string
+		want   string
+	}{
+		{"kelvin pod", `{"result":{"deviceId":"dev-1","leftUserId":"uid-123","leftKelvin":{"currentTargetTemp":300}}}`, "PUT /devices/dev-1"},
+		{"older pod", `{"result":{"deviceId":"dev-1","leftUserId":"uid-123","modelString":"Pod 2"}}`, "PUT /users/uid-123/temperature"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var puts []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPut {
+					puts = append(puts, r.Method+" "+r.URL.Path)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.device))
+			}))
+			defer srv.Close()
+			c := New("email", "pass", "uid-123", "", "")
+			c.BaseURL = srv.URL
+			c.DeviceID = "dev-1"
+			c.token = "t"
+			c.tokenExp = time.Now().Add(time.Hour)
+			c.HTTP = srv.Client()
+
+			if err := c.SetTemperatureVia(context.Background(), TempPathAuto, "", -20, 0); err != nil {
+				t.Fatalf("set temperature: %v", err)
+			}
+			if len(puts) != 1 || puts[0] != tt.want {
+				t.Fatalf("expected %s, got %v", tt.want, puts)
+			}
+		})
+	}
+}
+
+func TestTimedSideSettingUsesDeviceEndpoint(t *testing.T) {
+	var puts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"result":{"deviceId":"dev-1","leftUserId":"uid-123","rightUserId":"uid-456"}}`))
+			return
+		}
+		var body map[string]int
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for k, v := range body {
+			puts = append(puts, fmt.Sprintf("%s %s=%d", r.URL.Path, k, v))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.DeviceID = "dev-1"
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+
+	if err := c.SetTemperatureSide(context.Background(), SideRight, -20, 3600); err != nil {
+		t.Fatalf("set temperature: %v", err)
+	}
+	want := []string{"/devices/dev-1 rightTargetHeatingLevel=-20", "/devices/dev-1 rightHeatingDuration=3600"}
+	if strings.Join(puts, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, puts)
+	}
+}
+
 func Test429Retry(t *testing.T) {
 	count := 0
 	mux := http.NewServeMux()
@@ -192,3 +433,252 @@ func TestRetryExhaustion(t *testing.T) {
 		}
 	})
 }
+
+func TestRecentIntervals(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/uid-123/intervals", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"intervals":[{"id":"s2","ts":"2024-03-01T22:10:00.000Z","incomplete":true,"stages":[{"stage":"awake","duration":600},{"stage":"light","duration":1200}]},{"id":"s1","ts":"2024-02-29T22:00:00.000Z","score":81,"stages":[]}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+
+	got, err := c.Metrics().RecentIntervals(context.Background())
+	if err != nil {
+		t.Fatalf("intervals: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "s2" || !got[0].Incomplete || len(got[0].Stages) != 2 || got[0].Stages[1].Stage != "light" {
+		t.Fatalf("unexpected intervals: %+v", got)
+	}
+}
+
+func TestSleepDaysChunksLongRanges(t *testing.T) {
+	var ranges []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/uid-123/trends", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		ranges = append(ranges, q.Get("from")+".."+q.Get("to"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"days":[{"day":%q,"score":80}]}`, q.Get("from"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+
+	days, err := c.Metrics().SleepDays(context.Background(), "2024-01-01", "2024-03-30", "UTC")
+	if err != nil {
+		t.Fatalf("sleep days: %v", err)
+	}
+	if len(ranges) != 2 || ranges[0] != "2024-01-01..2024-02-29" || ranges[1] != "2024-03-01..2024-03-30" {
+		t.Fatalf("unexpected chunks: %v", ranges)
+	}
+	if len(days) != 2 || days[1].Date != "2024-03-01" || days[0].Stages == nil {
+		t.Fatalf("unexpected days: %+v", days)
+	}
+	if _, err := c.Metrics().SleepDays(context.Background(), "2024-01-02", "2024-01-01", "UTC"); err == nil {
+		t.Fatalf("expected error for reversed range")
+	}
+}
+
+func TestSessionsPagesUntilFrom(t *testing.T) {
+	var cursors []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/uid-123/intervals", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		next := r.URL.Query().Get("next")
+		cursors = append(cursors, next)
+		switch next {
+		case "":
+			_, _ = w.Write([]byte(`{"intervals":[{"id":"s3","ts":"2024-03-03T22:00:00Z","stages":[{"stage":"awake","duration":600}]},{"id":"s2","ts":"2024-03-02T22:00:00Z","score":88,"stages":[{"stage":"awake","duration":600},{"stage":"light","duration":1800},{"stage":"deep","duration":1200},{"stage":"out","duration":300}]}],"next":"p2"}`))
+		case "p2":
+			_, _ = w.Write([]byte(`{"intervals":[{"id":"s1","ts":"2024-03-01T22:00:00Z","stages":[]},{"id":"s0","ts":"2024-02-28T22:00:00Z","stages":[]}],"next":"p3"}`))
+		default:
+			t.Errorf("unexpected cursor %q", next)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+	got, truncated, err := c.Metrics().Sessions(context.Background(), from, to)
+	if err != nil || truncated {
+		t.Fatalf("sessions: %v (truncated %v)", err, truncated)
+	}
+	if len(got) != 2 || got[0].ID != "s2" || got[1].ID != "s1" {
+		t.Fatalf("unexpected sessions: %+v", got)
+	}
+	if got[0].AsleepSeconds != 3000 || !got[0].End.Equal(time.Date(2024, 3, 2, 23, 5, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected summary: %+v", got[0])
+	}
+	if len(cursors) != 2 {
+		t.Fatalf("expected paging to stop after passing from, got cursors %v", cursors)
+	}
+}
+
+func TestSessionsReportsTruncation(t *testing.T) {
+	pages := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/uid-123/intervals", func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"intervals":[{"id":"s%d","ts":"2024-03-03T22:00:00Z","stages":[]}],"next":"p%d"}`, pages, pages+1)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	got, truncated, err := c.Metrics().Sessions(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("sessions: %v", err)
+	}
+	if !truncated || pages != maxIntervalPages || len(got) != maxIntervalPages {
+		t.Fatalf("expected truncation after %d pages, got truncated=%v pages=%d sessions=%d", maxIntervalPages, truncated, pages, len(got))
+	}
+}
+
+func TestIntervalByID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/uid-123/intervals/s1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"intervals":[{"id":"s1","ts":"2024-03-01T22:00:00Z","stages":[{"stage":"light","duration":60}],"timeseries":{"heartRate":[["2024-03-01T22:00:30Z",58]],"tnt":[["2024-03-01T22:00:40Z",1]]}}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+
+	iv, err := c.Metrics().Interval(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("interval: %v", err)
+	}
+	if len(iv.Timeseries.HeartRate) != 1 || iv.Timeseries.HeartRate[0].Value != 58 || len(iv.Timeseries.TossAndTurns) != 1 {
+		t.Fatalf("unexpected timeseries: %+v", iv.Timeseries)
+	}
+	spans, err := iv.Timeline()
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	if len(spans) != 1 || !spans[0].End.Equal(time.Date(2024, 3, 1, 22, 1, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected timeline: %+v", spans)
+	}
+	if _, err := c.Metrics().Interval(context.Background(), "missing"); err == nil {
+		t.Fatalf("expected error for unknown session")
+	}
+}
+
+func TestIntervalAcceptsBareSession(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/uid-123/intervals/s1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"s1","ts":"2024-03-01T22:00:00Z","score":80,"stages":[{"stage":"light","duration":60}]}`))
+	})
+	mux.HandleFunc("/users/uid-123/intervals/s2", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"error":"unexpected"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+
+	iv, err := c.Metrics().Interval(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("interval: %v", err)
+	}
+	if iv.ID != "s1" || iv.Score != 80 || len(iv.Stages) != 1 {
+		t.Fatalf("unexpected interval: %+v", iv)
+	}
+	if _, err := c.Metrics().Interval(context.Background(), "s2"); err == nil {
+		t.Fatalf("expected error for a payload that is not a session")
+	}
+}
+
+type recordingObserver struct {
+	mu       sync.Mutex
+	statuses []int
+	retries  int
+}
+
+func (o *recordingObserver) APIError(status int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.statuses = append(o.statuses, status)
+}
+
+func (o *recordingObserver) Retry() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.retries++
+}
+
+func (o *recordingObserver) TokenRefresh() {}
+
+func TestObserverSeesErrorsAndRetries(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/uid-123/temperature", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/users/uid-123/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	obs := &recordingObserver{}
+	c := New("email", "pass", "uid-123", "", "")
+	c.BaseURL = srv.URL
+	c.token = "t"
+	c.tokenExp = time.Now().Add(time.Hour)
+	c.HTTP = srv.Client()
+	c.MaxRetries = 1
+	c.Observer = obs
+
+	if err := c.SetTemperature(context.Background(), 10); err != nil {
+		t.Fatalf("set temperature: %v", err)
+	}
+	if err := c.do(context.Background(), http.MethodGet, "/users/uid-123/missing", nil, nil, nil); err == nil {
+		t.Fatalf("expected 404 error")
+	}
+	if fmt.Sprint(obs.statuses) != "[503 404]" || obs.retries != 1 {
+		t.Fatalf("unexpected observations: statuses=%v retries=%d", obs.statuses, obs.retries)
+	}
+}
@@ -2,9 +2,11 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type MetricsActions struct{ c *Client }
@@ -26,10 +28,241 @@ func (m *MetricsActions) Trends(ctx context.Context, from, to, tz string, out an
 	return m.c.do(ctx, http.MethodGet, path, q, nil, out)
 }
 
+// maxTrendsDays caps how many days SleepDays asks /trends for in one request.
+const maxTrendsDays = 60
+
+// SleepDays fetches daily sleep metrics for from..to (YYYY-MM-DD, inclusive),
+// splitting long ranges into requests of at most maxTrendsDays. Days without
+// data are absent from the result.
+func (m *MetricsActions) SleepDays(ctx context.Context, from, to, tz string) ([]SleepDay, error) {
+	const layout = "2006-01-02"
+	start, err := time.Parse(layout, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date %q: %w", from, err)
+	}
+	end, err := time.Parse(layout, to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date %q: %w", to, err)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("to must be >= from")
+	}
+	days := []SleepDay{}
+	for chunk := start; !chunk.After(end); chunk = chunk.AddDate(0, 0, maxTrendsDays) {
+		chunkEnd := chunk.AddDate(0, 0, maxTrendsDays-1)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		var res struct {
+			Days []SleepDay `json:"days"`
+		}
+		if err := m.Trends(ctx, chunk.Format(layout), chunkEnd.Format(layout), tz, &res); err != nil {
+			return nil, err
+		}
+		for _, d := range res.Days {
+			days = append(days, d.normalized())
+		}
+	}
+	return days, nil
+}
+
 func (m *MetricsActions) Intervals(ctx context.Context, sessionID string, out any) error {
 	if err := m.c.requireUser(ctx); err != nil {
 		return err
 	}
-	path := fmt.Sprintf("/users/%s/intervals/%s", m.c.UserID, sessionID)
+	path := fmt.Sprintf("/users/%s/intervals/%s", m.c.UserID, url.PathEscape(sessionID))
 	return m.c.do(ctx, http.MethodGet, path, nil, nil, out)
 }
+
+// Interval fetches a single sleep session by ID. The session may come back
+// bare or wrapped in an intervals list; both are accepted.
+func (m *MetricsActions) Interval(ctx context.Context, sessionID string) (*Interval, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("session id is required")
+	}
+	var body json.RawMessage
+	if err := m.Intervals(ctx, sessionID, &body); err != nil {
+		return nil, err
+	}
+	var wrapped struct {
+		Intervals []Interval `json:"intervals"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", sessionID, err)
+	}
+	if wrapped.Intervals != nil {
+		for i := range wrapped.Intervals {
+			if wrapped.Intervals[i].ID == sessionID {
+				return &wrapped.Intervals[i], nil
+			}
+		}
+		return nil, fmt.Errorf("session %s not found", sessionID)
+	}
+	var iv Interval
+	if err := json.Unmarshal(body, &iv); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", sessionID, err)
+	}
+	if iv.ID == "" && iv.Start == "" {
+		return nil, fmt.Errorf("session %s: response is neither a session nor an intervals list", sessionID)
+	}
+	if iv.ID != "" && iv.ID != sessionID {
+		return nil, fmt.Errorf("session %s not found", sessionID)
+	}
+	iv.ID = sessionID
+	return &iv, nil
+}
+
+// Interval is one sleep session as reported by the intervals endpoint. Stages
+// run back to back from Start; Incomplete marks the session still in progress.
+type Interval struct {
+	ID         string             `json:"id"`
+	Start      string             `json:"ts"`
+	Score      float64            `json:"score"`
+	Incomplete bool               `json:"incomplete"`
+	Stages     []Stage            `json:"stages"`
+	Timeseries IntervalTimeseries `json:"timeseries"`
+}
+
+// IntervalTimeseries holds the signals sampled during a session.
+type IntervalTimeseries struct {
+	HeartRate       []TimeseriesPoint `json:"heartRate"`
+	HRV             []TimeseriesPoint `json:"hrv"`
+	RespiratoryRate []TimeseriesPoint `json:"respiratoryRate"`
+	TempBedC        []TimeseriesPoint `json:"tempBedC"`
+	TempRoomC       []TimeseriesPoint `json:"tempRoomC"`
+	TossAndTurns    []TimeseriesPoint `json:"tnt"`
+}
+
+// StageSpan is one stage of an interval placed on the wall clock.
+type StageSpan struct {
+	Stage string
+	Start time.Time
+	End   time.Time
+}
+
+// StartTime parses the interval's start timestamp.
+func (iv Interval) StartTime() (time.Time, error) {
+	start, err := time.Parse(time.RFC3339, iv.Start)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("interval %s: parse ts %q: %w", iv.ID, iv.Start, err)
+	}
+	return start, nil
+}
+
+// Timeline lays the stages out back to back from the interval's start.
+func (iv Interval) Timeline() ([]StageSpan, error) {
+	start, err := iv.StartTime()
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]StageSpan, 0, len(iv.Stages))
+	at := start
+	for _, s := range iv.Stages {
+		end := at.Add(time.Duration(s.Duration * float64(time.Second)))
+		spans = append(spans, StageSpan{Stage: s.Stage, Start: at, End: end})
+		at = end
+	}
+	return spans, nil
+}
+
+// Session summarises an interval for listings.
+type Session struct {
+	ID    string    `json:"id"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Score float64   `json:"score"`
+	// AsleepSeconds totals the light, deep and REM stages.
+	AsleepSeconds float64 `json:"asleepSeconds"`
+	Incomplete    bool    `json:"incomplete"`
+}
+
+// Session summarises the interval.
+func (iv Interval) Session() (Session, error) {
+	spans, err := iv.Timeline()
+	if err != nil {
+		return Session{}, err
+	}
+	start, _ := iv.StartTime()
+	s := Session{ID: iv.ID, Start: start, End: start, Score: iv.Score, Incomplete: iv.Incomplete}
+	for _, sp := range spans {
+		switch sp.Stage {
+		case "light", "deep", "rem":
+			s.AsleepSeconds += sp.End.Sub(sp.Start).Seconds()
+		}
+		s.End = sp.End
+	}
+	return s, nil
+}
+
+// maxIntervalPages bounds how far back Sessions pages through history.
+const maxIntervalPages = 50
+
+// intervalsPage fetches one page of sessions, newest first, and the cursor for
+// the next (older) page.
+func (m *MetricsActions) intervalsPage(ctx context.Context, next string) ([]Interval, string, error) {
+	var q url.Values
+	if next != "" {
+		q = url.Values{}
+		q.Set("next", next)
+	}
+	path := fmt.Sprintf("/users/%s/intervals", m.c.UserID)
+	var res struct {
+		Intervals []Interval `json:"intervals"`
+		Next      string     `json:"next"`
+	}
+	if err := m.c.do(ctx, http.MethodGet, path, q, nil, &res); err != nil {
+		return nil, "", err
+	}
+	return res.Intervals, res.Next, nil
+}
+
+// RecentIntervals returns the user's latest sleep sessions, newest first.
+func (m *MetricsActions) RecentIntervals(ctx context.Context) ([]Interval, error) {
+	if err := m.c.requireUser(ctx); err != nil {
+		return nil, err
+	}
+	intervals, _, err := m.intervalsPage(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if intervals == nil {
+		intervals = []Interval{}
+	}
+	return intervals, nil
+}
+
+// Sessions lists the sessions that started in [from, to), newest first,
+// paging back through history until it passes from. truncated reports that
+// paging stopped at maxIntervalPages first, so older sessions may be missing.
+func (m *MetricsActions) Sessions(ctx context.Context, from, to time.Time) (sessions []Session, truncated bool, err error) {
+	if err := m.c.requireUser(ctx); err != nil {
+		return nil, false, err
+	}
+	out := []Session{}
+	next := ""
+	for page := 0; page < maxIntervalPages; page++ {
+		intervals, cursor, err := m.intervalsPage(ctx, next)
+		if err != nil {
+			return nil, false, err
+		}
+		done := cursor == "" || len(intervals) == 0
+		for _, iv := range intervals {
+			s, err := iv.Session()
+			if err != nil {
+				return nil, false, err
+			}
+			if s.Start.Before(from) {
+				done = true
+				continue
+			}
+			if s.Start.Before(to) {
+				out = append(out, s)
+			}
+		}
+		if done {
+			return out, false, nil
+		}
+		next = cursor
+	}
+	return out, true, nil
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Side identifies one half of the bed, or both halves.
+type Side string
+
+const (
+	SideLeft  Side = "left"
+	SideRight Side = "right"
+	SideBoth  Side = "both"
+)
+
+// ParseSide validates a user-supplied side name.
+func ParseSide(s string) (Side, error) {
+	switch side := Side(strings.ToLower(strings.TrimSpace(s))); side {
+	case SideLeft, SideRight, SideBoth:
+		return side, nil
+	default:
+		return "", fmt.Errorf("invalid side %q (allowed: left, right, both)", s)
+	}
+}
+
+// Sides expands SideBoth into its halves.
+func (s Side) Sides() []Side {
+	if s == SideBoth {
+		return []Side{SideLeft, SideRight}
+	}
+	return []Side{s}
+}
+
+// UserID returns the user assigned to a single side of the device.
+func (d *Device) UserID(side Side) string {
+	switch side {
+	case SideLeft:
+		return d.LeftUserID
+	case SideRight:
+		return d.RightUserID
+	default:
+		return ""
+	}
+}
+
+// SideStatus is the temperature state for one side of the bed.
+type SideStatus struct {
+	Side   Side
+	UserID string
+	TempStatus
+}
+
+type sideUser struct {
+	Side   Side
+	UserID string
+}
+
+// UserSide determines which side of the bed the authenticated user occupies.
+func (c *Client) UserSide(ctx context.Context) (Side, error) {
+	if err := c.requireUser(ctx); err != nil {
+		return "", err
+	}
+	dev, err := c.Device().Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("resolve side: %w", err)
+	}
+	return dev.SideOf(c.UserID)
+}
+
+// SideOf returns the side userID is assigned to on the device.
+func (d *Device) SideOf(userID string) (Side, error) {
+	switch userID {
+	case d.LeftUserID:
+		return SideLeft, nil
+	case d.RightUserID:
+		return SideRight, nil
+	}
+	return "", fmt.Errorf("user %s is not assigned to a side of device %s", userID, d.ID)
+}
+
+// sideUsers resolves the user IDs assigned to the requested side(s).
+func (c *Client) sideUsers(ctx context.Context, side Side) ([]sideUser, error) {
+	if _, err := ParseSide(string(side)); err != nil {
+		return nil, err
+	}
+	dev, err := c.Device().Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s side: %w", side, err)
+	}
+	out := make([]sideUser, 0, 2)
+	for _, s := range side.Sides() {
+		uid := dev.UserID(s)
+		if uid == "" {
+			return nil, fmt.Errorf("no user assigned to %s side", s)
+		}
+		out = append(out, sideUser{Side: s, UserID: uid})
+	}
+	return out, nil
+}
+
+// TurnOnSide powers on the given side(s) of the bed.
+func (c *Client) TurnOnSide(ctx context.Context, side Side) error {
+	users, err := c.sideUsers(ctx, side)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := c.setUserState(ctx, u.UserID, "smart"); err != nil {
+			return fmt.Errorf("%s side: %w", u.Side, err)
+		}
+	}
+	return nil
+}
+
+// TurnOffSide powers off the given side(s) of the bed.
+func (c *Client) TurnOffSide(ctx context.Context, side Side) error {
+	users, err := c.sideUsers(ctx, side)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := c.setUserState(ctx, u.UserID, "off"); err != nil {
+			return fmt.Errorf("%s side: %w", u.Side, err)
+		}
+	}
+	return nil
+}
+
+// SetTemperatureSide sets the heating level (-100..100) on the given side(s).
+// duration is in seconds; 0 leaves the level in place indefinitely. The user
+// endpoint has no known timed setting, so a duration goes through the device
+// endpoint's per-side heating duration instead.
+func (c *Client) SetTemperatureSide(ctx context.Context, side Side, level int, duration int) error {
+	if level < -100 || level > 100 {
+		return fmt.Errorf("level must be between -100 and 100")
+	}
+	if duration > 0 {
+		return c.SetTemperatureDeviceSide(ctx, side, level, duration)
+	}
+	users, err := c.sideUsers(ctx, side)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := c.setUserLevel(ctx, u.UserID, level); err != nil {
+			return fmt.Errorf("%s side: %w", u.Side, err)
+		}
+	}
+	return nil
+}
+
+// GetStatusSide fetches temperature state for the given side(s).
+func (c *Client) GetStatusSide(ctx context.Context, side Side) ([]SideStatus, error) {
+	users, err := c.sideUsers(ctx, side)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SideStatus, 0, len(users))
+	for _, u := range users {
+		st, err := c.userStatus(ctx, u.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%s side: %w", u.Side, err)
+		}
+		out = append(out, SideStatus{Side: u.Side, UserID: u.UserID, TempStatus: *st})
+	}
+	return out, nil
+}
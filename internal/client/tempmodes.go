@@ -54,6 +54,26 @@ func (t *TempModeActions) TempEvents(ctx context.Context, from, to string, out a
 	return t.c.doApp(ctx, http.MethodGet, path, q, nil, out)
 }
 
+// TempEvent is one heating level change reported by the temp-events endpoint.
+type TempEvent struct {
+	Time  string `json:"ts"`
+	Level int    `json:"level"`
+}
+
+// Events returns the typed temperature events between from and to (YYYY-MM-DD).
+func (t *TempModeActions) Events(ctx context.Context, from, to string) ([]TempEvent, error) {
+	var res struct {
+		Events []TempEvent `json:"events"`
+	}
+	if err := t.TempEvents(ctx, from, to, &res); err != nil {
+		return nil, err
+	}
+	if res.Events == nil {
+		res.Events = []TempEvent{}
+	}
+	return res.Events, nil
+}
+
 func (t *TempModeActions) simplePost(ctx context.Context, suffix string) error {
 	if err := t.c.requireUser(ctx); err != nil {
 		return err
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TempPath selects which endpoint applies temperature changes.
+type TempPath string
+
+const (
+	TempPathAuto   TempPath = "auto"
+	TempPathDevice TempPath = "device"
+	TempPathUser   TempPath = "user"
+)
+
+// ParseTempPath validates a user-supplied temperature path.
+func ParseTempPath(s string) (TempPath, error) {
+	switch p := TempPath(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return TempPathAuto, nil
+	case TempPathAuto, TempPathDevice, TempPathUser:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid temperature path %q (allowed: auto, device, user)", s)
+	}
+}
+
+// ResolveTempPath replaces TempPathAuto with the endpoint suited to the pod:
+// kelvin-based pods need the device endpoint, older pods use the user endpoint.
+func (c *Client) ResolveTempPath(ctx context.Context, p TempPath) (TempPath, error) {
+	if p != TempPathAuto {
+		return p, nil
+	}
+	dev, err := c.Device().Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("detect pod generation: %w", err)
+	}
+	return dev.TempPath(), nil
+}
+
+// SetTemperatureVia sets the level through path, resolving TempPathAuto from
+// the device payload, for duration seconds (zero holds it indefinitely). An
+// empty side is the caller's own side wherever the endpoint is keyed by side:
+// the device endpoint and timed settings.
+func (c *Client) SetTemperatureVia(ctx context.Context, path TempPath, side Side, level int, duration int) error {
+	// Auto detection and side lookup both read the device; fetch it once.
+	var dev *Device
+	if path == TempPathAuto {
+		var err error
+		if dev, err = c.Device().Info(ctx); err != nil {
+			return fmt.Errorf("detect pod generation: %w", err)
+		}
+		path = dev.TempPath()
+	}
+	if side == "" && (path == TempPathDevice || duration > 0) {
+		var err error
+		if dev == nil {
+			side, err = c.UserSide(ctx)
+		} else if err = c.EnsureUserID(ctx); err == nil {
+			side, err = dev.SideOf(c.UserID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	switch {
+	case path == TempPathDevice:
+		return c.SetTemperatureDeviceSide(ctx, side, level, duration)
+	case side != "":
+		return c.SetTemperatureSide(ctx, side, level, duration)
+	default:
+		return c.SetTemperature(ctx, level)
+	}
+}
+
+// TempPath is the endpoint suited to the pod, as ResolveTempPath picks for auto.
+func (d *Device) TempPath() TempPath {
+	if d.KelvinBased() {
+		return TempPathDevice
+	}
+	return TempPathUser
+}
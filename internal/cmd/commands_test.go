@@ -2,10 +2,18 @@ package cmd
 
 import (
 	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+	"github.com/salmonumbrella/eightsleep-cli/internal/daemon"
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
 )
 
 func TestStatusCommandJSON(t *testing.T) {
@@ -26,6 +34,9 @@ func TestStatusCommandJSON(t *testing.T) {
 	if rows[0]["mode"] != "on" {
 		t.Fatalf("expected mode on, got %v", rows[0]["mode"])
 	}
+	if rows[0]["temp"] != 83.5 {
+		t.Fatalf("expected temp 83.5, got %v", rows[0]["temp"])
+	}
 }
 
 func TestStatusCommandInvalidFields(t *testing.T) {
@@ -49,6 +60,121 @@ func TestOnOffTempCommands(t *testing.T) {
 	}
 }
 
+func TestTempCommandViaAndDuration(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, tempCmd)
+	for _, via := range []string{"device", "user", "auto"} {
+		if err := tempCmd.Flags().Set("via", via); err != nil {
+			t.Fatalf("set via: %v", err)
+		}
+		if err := tempCmd.Flags().Set("duration", "2h"); err != nil {
+			t.Fatalf("set duration: %v", err)
+		}
+		out := captureStdout(t, func() {
+			if err := tempCmd.RunE(tempCmd, []string{"20"}); err != nil {
+				t.Fatalf("temp via %s: %v", via, err)
+			}
+		})
+		if !strings.Contains(out, "for 2h0m0s") {
+			t.Fatalf("expected duration in output, got %q", out)
+		}
+	}
+	if err := tempCmd.Flags().Set("via", "bluetooth"); err != nil {
+		t.Fatalf("set via: %v", err)
+	}
+	if err := tempCmd.RunE(tempCmd, []string{"20"}); err == nil {
+		t.Fatalf("expected invalid via error")
+	}
+	if err := tempCmd.Flags().Set("via", "auto"); err != nil {
+		t.Fatalf("set via: %v", err)
+	}
+	if err := tempCmd.Flags().Set("duration", "soon"); err == nil {
+		t.Fatalf("expected invalid duration error")
+	}
+	if err := tempCmd.Flags().Set("duration", "-1h"); err != nil {
+		t.Fatalf("set duration: %v", err)
+	}
+	if err := tempCmd.RunE(tempCmd, []string{"20"}); err == nil {
+		t.Fatalf("expected negative duration error")
+	}
+}
+
+func TestTempCommandAutoReadsDeviceOnce(t *testing.T) {
+	c := setupTestEnv(t)
+	resetFlagsOnCleanup(t, tempCmd)
+	infos := 0
+	base := c.HTTP.Transport
+	c.HTTP.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method == http.MethodGet && r.URL.Path == "/devices/dev-1" {
+			infos++
+		}
+		return base.RoundTrip(r)
+	})
+	if err := tempCmd.Flags().Set("duration", "2h"); err != nil {
+		t.Fatalf("set duration: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := tempCmd.RunE(tempCmd, []string{"20"}); err != nil {
+			t.Fatalf("temp: %v", err)
+		}
+	})
+	if infos != 1 {
+		t.Fatalf("expected one device read for auto mode, got %d", infos)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestStatusCommandBothSides(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, statusCmd)
+	if err := statusCmd.Flags().Set("side", "both"); err != nil {
+		t.Fatalf("set side: %v", err)
+	}
+	out := captureStdout(t, func() {
+		if err := statusCmd.RunE(statusCmd, []string{}); err != nil {
+			t.Fatalf("status: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0]["side"] != "left" || rows[1]["side"] != "right" || rows[1]["mode"] != "smart" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+}
+
+func TestSideFlagCommands(t *testing.T) {
+	setupTestEnv(t)
+	for _, c := range []*cobra.Command{onCmd, offCmd, tempCmd} {
+		resetFlagsOnCleanup(t, c)
+		if err := c.Flags().Set("side", "right"); err != nil {
+			t.Fatalf("set side: %v", err)
+		}
+	}
+	if err := onCmd.RunE(onCmd, []string{}); err != nil {
+		t.Fatalf("on: %v", err)
+	}
+	if err := offCmd.RunE(offCmd, []string{}); err != nil {
+		t.Fatalf("off: %v", err)
+	}
+	if err := tempCmd.RunE(tempCmd, []string{"-10"}); err != nil {
+		t.Fatalf("temp: %v", err)
+	}
+	if err := tempCmd.Flags().Set("side", "middle"); err != nil {
+		t.Fatalf("set side: %v", err)
+	}
+	if err := tempCmd.RunE(tempCmd, []string{"-10"}); err == nil {
+		t.Fatalf("expected invalid side error")
+	}
+}
+
 func TestSleepDayCommand(t *testing.T) {
 	setupTestEnv(t)
 	viper.Set("date", "2024-01-01")
@@ -67,6 +193,127 @@ func TestSleepDayCommand(t *testing.T) {
 	if rows[0]["date"] != "2024-01-01" {
 		t.Fatalf("expected date 2024-01-01, got %v", rows[0]["date"])
 	}
+	if rows[0]["bed_temp"] != 87.8 || rows[0]["room_temp"] != float64(68) {
+		t.Fatalf("expected bed/room temps in F, got %v/%v", rows[0]["bed_temp"], rows[0]["room_temp"])
+	}
+}
+
+func TestSleepDayStages(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, sleepDayCmd)
+	viper.Set("date", "2024-01-01")
+	if err := sleepDayCmd.Flags().Set("stages", "true"); err != nil {
+		t.Fatalf("set stages: %v", err)
+	}
+	out := captureStdout(t, func() {
+		if err := sleepDayCmd.RunE(sleepDayCmd, []string{}); err != nil {
+			t.Fatalf("sleep day: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	want := []struct {
+		stage            string
+		minutes, percent float64
+	}{
+		{"awake", 30, 6.7},
+		{"light", 210, 46.7},
+		{"deep", 60, 13.3},
+		{"rem", 150, 33.3},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d stages, got %v", len(want), rows)
+	}
+	for i, w := range want {
+		if rows[i]["stage"] != w.stage || rows[i]["minutes"] != w.minutes || rows[i]["percent"] != w.percent {
+			t.Fatalf("row %d = %v, want %+v", i, rows[i], w)
+		}
+	}
+}
+
+func TestStageBreakdownWithoutStages(t *testing.T) {
+	setupTestEnv(t)
+	out := captureStdout(t, func() {
+		if err := printStageBreakdown(output.FormatCSV, &client.SleepDay{Date: "2024-01-05"}); err != nil {
+			t.Fatalf("print: %v", err)
+		}
+	})
+	if out != "stage,minutes,percent\nawake,0,\nlight,0,\ndeep,0,\nrem,0," {
+		t.Fatalf("expected blank shares without stages, got %q", out)
+	}
+}
+
+func TestSleepDayCommandCelsiusTable(t *testing.T) {
+	setupTestEnv(t)
+	viper.Set("date", "2024-01-01")
+	viper.Set("temp_unit", "C")
+	viper.Set("output", "table")
+	out := captureStdout(t, func() {
+		if err := sleepDayCmd.RunE(sleepDayCmd, []string{}); err != nil {
+			t.Fatalf("sleep day: %v", err)
+		}
+	})
+	if !strings.Contains(out, "31°C") || !strings.Contains(out, "20°C") {
+		t.Fatalf("expected celsius temps in table, got %q", out)
+	}
+}
+
+func TestSleepDayHumanDurations(t *testing.T) {
+	setupTestEnv(t)
+	viper.Set("date", "2024-01-01")
+	viper.Set("output", "table")
+	out := captureStdout(t, func() {
+		if err := sleepDayCmd.RunE(sleepDayCmd, []string{}); err != nil {
+			t.Fatalf("sleep day: %v", err)
+		}
+	})
+	if !strings.Contains(out, "8h 0m") || !strings.Contains(out, "5m") || !strings.Contains(out, "2m") {
+		t.Fatalf("expected human durations in table, got %q", out)
+	}
+
+	viper.Set("output", "json")
+	viper.Set("fields", []string{"duration"})
+	raw := captureStdout(t, func() {
+		if err := sleepDayCmd.RunE(sleepDayCmd, []string{}); err != nil {
+			t.Fatalf("sleep day: %v", err)
+		}
+	})
+	if raw != "28800" {
+		t.Fatalf("expected raw seconds in json, got %q", raw)
+	}
+	viper.Set("human", true)
+	human := captureStdout(t, func() {
+		if err := sleepDayCmd.RunE(sleepDayCmd, []string{}); err != nil {
+			t.Fatalf("sleep day: %v", err)
+		}
+	})
+	if human != `"8h 0m"` {
+		t.Fatalf("expected human duration with --human, got %q", human)
+	}
+}
+
+func TestTemperatureTableOverride(t *testing.T) {
+	setupTestEnv(t)
+	viper.Set("temp_calibration", []map[string]any{
+		{"level": -100, "fahrenheit": 60},
+		{"level": 100, "fahrenheit": 100},
+	})
+	table, err := temperatureTable()
+	if err != nil {
+		t.Fatalf("temperatureTable: %v", err)
+	}
+	if got := table.ToFahrenheit(0); got != 80 {
+		t.Fatalf("expected overridden midpoint 80F, got %v", got)
+	}
+	viper.Set("temp_calibration", []map[string]any{
+		{"level": 10, "fahrenheit": 60},
+		{"level": 0, "fahrenheit": 100},
+	})
+	if _, err := temperatureTable(); err == nil {
+		t.Fatalf("expected invalid calibration error")
+	}
 }
 
 func TestSleepRangeCommand(t *testing.T) {
@@ -90,12 +337,360 @@ func TestSleepRangeCommand(t *testing.T) {
 	if len(rows) != 2 {
 		t.Fatalf("expected 2 rows, got %d", len(rows))
 	}
+	if rows[1]["date"] != "2024-01-02" || rows[1]["score"] != nil {
+		t.Fatalf("expected empty row for missing day, got %v", rows[1])
+	}
+
+	viper.Set("output", "csv")
+	viper.Set("fields", []string{"date", "score"})
+	out = captureStdout(t, func() {
+		if err := sleepRangeCmd.RunE(sleepRangeCmd, []string{}); err != nil {
+			t.Fatalf("sleep range csv: %v", err)
+		}
+	})
+	if !strings.HasSuffix(out, "\n2024-01-02,") {
+		t.Fatalf("expected blank score for missing day in csv, got %q", out)
+	}
+}
+
+func TestSleepRangeStages(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, sleepRangeCmd)
+	viper.Set("fields", []string{"date", "light_min", "rem_pct"})
+	for name, value := range map[string]string{"from": "2024-01-01", "to": "2024-01-02", "stages": "true"} {
+		if err := sleepRangeCmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("set %s: %v", name, err)
+		}
+	}
+	out := captureStdout(t, func() {
+		if err := sleepRangeCmd.RunE(sleepRangeCmd, []string{}); err != nil {
+			t.Fatalf("sleep range: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["light_min"] != float64(210) || rows[0]["rem_pct"] != 33.3 || rows[1]["light_min"] != nil {
+		t.Fatalf("unexpected stage columns: %v", rows)
+	}
+}
+
+func TestSleepRangeSkipMissing(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, sleepRangeCmd)
+	for name, value := range map[string]string{"from": "2023-12-31", "to": "2024-01-02", "skip-missing": "true"} {
+		if err := sleepRangeCmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("set %s: %v", name, err)
+		}
+	}
+	out := captureStdout(t, func() {
+		if err := sleepRangeCmd.RunE(sleepRangeCmd, []string{}); err != nil {
+			t.Fatalf("sleep range: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["date"] != "2024-01-01" {
+		t.Fatalf("expected only the day with data, got %v", rows)
+	}
+}
+
+func TestSleepStatsCommand(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, sleepStatsCmd)
+	viper.Set("fields", []string{"group", "metric", "n", "mean", "stddev"})
+	for name, value := range map[string]string{"from": "2024-01-01", "to": "2024-01-07", "group-by": "week"} {
+		if err := sleepStatsCmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("set %s: %v", name, err)
+		}
+	}
+	out := captureStdout(t, func() {
+		if err := sleepStatsCmd.RunE(sleepStatsCmd, []string{}); err != nil {
+			t.Fatalf("sleep stats: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != len(sleepMetrics) || rows[0]["group"] != "2024-W01" || rows[0]["metric"] != "score" || rows[0]["mean"] != float64(90) {
+		t.Fatalf("unexpected stats: %v", rows)
+	}
+	if _, ok := rows[0]["median"]; ok || rows[0]["stddev"] != nil {
+		t.Fatalf("expected filtered fields and nil stddev for a single night, got %v", rows[0])
+	}
+	if err := sleepStatsCmd.Flags().Set("group-by", "year"); err != nil {
+		t.Fatalf("set group-by: %v", err)
+	}
+	if err := sleepStatsCmd.RunE(sleepStatsCmd, []string{}); err == nil {
+		t.Fatalf("expected invalid group-by error")
+	}
+}
+
+func TestSleepStatsTableFormatsByMetric(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, sleepStatsCmd)
+	viper.Set("output", "csv")
+	viper.Set("fields", []string{"metric", "mean", "max"})
+	for name, value := range map[string]string{"from": "2024-01-01", "to": "2024-01-01"} {
+		if err := sleepStatsCmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("set %s: %v", name, err)
+		}
+	}
+	run := func() string {
+		return captureStdout(t, func() {
+			if err := sleepStatsCmd.RunE(sleepStatsCmd, []string{}); err != nil {
+				t.Fatalf("sleep stats: %v", err)
+			}
+		})
+	}
+	if out := run(); !strings.Contains(out, "\nduration,28800,28800\n") {
+		t.Fatalf("expected raw seconds in csv, got %q", out)
+	}
+	viper.Set("human", true)
+	out := run()
+	for _, want := range []string{"\nscore,90,90\n", "\nduration,8h 0m,8h 0m\n", "\nlatency_asleep,5m,5m"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in human output, got %q", want, out)
+		}
+	}
+}
+
+func TestSleepStatsRowsByWeekday(t *testing.T) {
+	day := func(date string, score float64) client.SleepDay {
+		return client.SleepDay{Date: date, Score: score, Duration: 25000}
+	}
+	keyFn, err := sleepGroupKey("weekday")
+	if err != nil {
+		t.Fatalf("group key: %v", err)
+	}
+	rows, err := sleepStatsRows([]client.SleepDay{
+		day("2024-01-07", 70),
+		day("2024-01-01", 80),
+		day("2024-01-08", 90),
+		{Date: "2024-01-02"},
+	}, keyFn)
+	if err != nil {
+		t.Fatalf("stats rows: %v", err)
+	}
+	if len(rows) != 2*len(sleepMetrics) {
+		t.Fatalf("expected Mon and Sun groups only, got %d rows", len(rows))
+	}
+	if rows[0]["group"] != "Mon" || rows[0]["n"] != 2 || rows[0]["mean"] != float64(85) || rows[0]["stddev"] != 7.1 {
+		t.Fatalf("unexpected Monday score row: %v", rows[0])
+	}
+	if rows[len(sleepMetrics)]["group"] != "Sun" {
+		t.Fatalf("expected Sunday last, got %v", rows[len(sleepMetrics)])
+	}
+}
+
+func TestSleepCompareRows(t *testing.T) {
+	nights := func(scores ...float64) []client.SleepDay {
+		days := make([]client.SleepDay, 0, len(scores))
+		for _, sc := range scores {
+			days = append(days, client.SleepDay{Score: sc, Duration: 25000})
+		}
+		return days
+	}
+	rows := sleepCompareRows(nights(70, 72, 68, 71, 69), nights(80, 82, 79, 81, 83), 0.05)
+	if len(rows) != len(sleepMetrics) {
+		t.Fatalf("expected one row per metric, got %d", len(rows))
+	}
+	score := rows[0]
+	if score["metric"] != "score" || score["delta"] != float64(11) || score["change_pct"] != 15.7 || score["significant"] != true {
+		t.Fatalf("unexpected score row: %v", score)
+	}
+	duration := rows[1]
+	if duration["delta"] != float64(0) || duration["change_pct"] != float64(0) || duration["p_value"] != nil || duration["significant"] != false {
+		t.Fatalf("expected untestable constant duration, got %v", duration)
+	}
+	hr := rows[2]
+	if hr["change_pct"] != nil {
+		t.Fatalf("expected nil change for zero baseline, got %v", hr)
+	}
+	out := captureStdout(t, func() {
+		if err := output.Print(output.FormatCSV, []string{"metric", "change_pct", "p_value", "significant"}, rows[2:3]); err != nil {
+			t.Fatalf("print: %v", err)
+		}
+	})
+	if !strings.HasSuffix(out, "\nheart_rate,,,false") {
+		t.Fatalf("expected blank change_pct and p_value, got %q", out)
+	}
+}
+
+func TestSleepCompareCommand(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, sleepCompareCmd)
+	if err := sleepCompareCmd.Flags().Set("a", "2024-01-01..2024-01-07"); err != nil {
+		t.Fatalf("set a: %v", err)
+	}
+	if err := sleepCompareCmd.Flags().Set("b", "2024-01-08.."); err != nil {
+		t.Fatalf("set b: %v", err)
+	}
+	if err := sleepCompareCmd.RunE(sleepCompareCmd, []string{}); err == nil || !strings.Contains(err.Error(), "--b") {
+		t.Fatalf("expected invalid --b range error, got %v", err)
+	}
+	if err := sleepCompareCmd.Flags().Set("b", "2024-01-08..2024-01-14"); err != nil {
+		t.Fatalf("set b: %v", err)
+	}
+	out := captureStdout(t, func() {
+		if err := sleepCompareCmd.RunE(sleepCompareCmd, []string{}); err != nil {
+			t.Fatalf("sleep compare: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != len(sleepMetrics) || rows[0]["n_a"] != float64(1) || rows[0]["p_value"] != nil {
+		t.Fatalf("unexpected comparison: %v", rows)
+	}
+}
+
+func TestCorrelateBandRows(t *testing.T) {
+	night := func(date string, score float64) client.SleepDay {
+		return client.SleepDay{Date: date, Score: score, Duration: 25000}
+	}
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("parse %s: %v", s, err)
+		}
+		return ts
+	}
+	nights := joinNightLevels([]client.SleepDay{night("2024-01-02", 90), night("2024-01-01", 70), night("2024-01-03", 80)}, []levelChange{
+		{at: at("2023-12-31T22:00:00Z"), level: 0},
+		{at: at("2024-01-01T04:00:00Z"), level: 10},
+		{at: at("2024-01-01T21:00:00Z"), level: -25},
+	}, time.UTC)
+	if len(nights) != 2 || nights[0].day.Date != "2024-01-01" || roundTo(nights[0].level, 2) != 5.71 || nights[1].level != -25 {
+		t.Fatalf("unexpected nights: %+v", nights)
+	}
+	rows := correlateBandRows(nights, 10)
+	if len(rows) != 2 || rows[0]["band"] != "-30..-21" || rows[0]["score"] != float64(90) || rows[0]["score_delta"] != float64(10) {
+		t.Fatalf("unexpected band rows: %v", rows)
+	}
+	if rows[1]["band"] != "0..9" || rows[1]["nights"] != 1 {
+		t.Fatalf("unexpected second band: %v", rows[1])
+	}
+	corr := correlateMetricRows(nights, 0.05)
+	if len(corr) != len(correlateMetrics) || corr[0]["r"] != nil {
+		t.Fatalf("expected no correlation with two nights, got %v", corr)
+	}
+	out := captureStdout(t, func() {
+		if err := output.Print(output.FormatCSV, correlateMetricFields, corr[:1]); err != nil {
+			t.Fatalf("print: %v", err)
+		}
+	})
+	if !strings.HasSuffix(out, "\nscore,2,,,false") {
+		t.Fatalf("expected blank r and p_value, got %q", out)
+	}
+}
+
+func TestSleepCorrelateCommand(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, sleepCorrelateCmd)
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := daemon.NewJournal(path)
+	for _, e := range []daemon.JournalEntry{
+		{Time: time.Date(2023, 12, 31, 22, 0, 0, 0, time.UTC), Action: "temp", Temperature: "-20", Result: daemon.ResultOK},
+		{Time: time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC), Action: "temp", Temperature: "50", Result: daemon.ResultError},
+	} {
+		if err := j.Append(e); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	for name, value := range map[string]string{"from": "2024-01-01", "to": "2024-01-01", "journal": path} {
+		if err := sleepCorrelateCmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("set %s: %v", name, err)
+		}
+	}
+	run := func() []map[string]any {
+		out := captureStdout(t, func() {
+			if err := sleepCorrelateCmd.RunE(sleepCorrelateCmd, []string{}); err != nil {
+				t.Fatalf("sleep correlate: %v", err)
+			}
+		})
+		var rows []map[string]any
+		if err := json.Unmarshal([]byte(out), &rows); err != nil {
+			t.Fatalf("parse json: %v", err)
+		}
+		return rows
+	}
+	rows := run()
+	if len(rows) != 1 || rows[0]["band"] != "-20..-11" || rows[0]["level"] != float64(-20) || rows[0]["score"] != float64(90) {
+		t.Fatalf("unexpected journal bands: %v", rows)
+	}
+	if err := sleepCorrelateCmd.Flags().Set("source", "events"); err != nil {
+		t.Fatalf("set source: %v", err)
+	}
+	rows = run()
+	// -10 holds for 5h and -30 for the 9h until noon, so the night averages -22.9.
+	if len(rows) != 1 || rows[0]["band"] != "-30..-21" || rows[0]["level"] != -22.9 || rows[0]["nights"] != float64(1) {
+		t.Fatalf("unexpected event bands: %v", rows)
+	}
+}
+
+func TestSleepSessionsCommand(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, sleepSessionsCmd)
+	if err := sleepSessionsCmd.Flags().Set("from", "2024-01-01"); err != nil {
+		t.Fatalf("set from: %v", err)
+	}
+	if err := sleepSessionsCmd.Flags().Set("to", "2024-01-01"); err != nil {
+		t.Fatalf("set to: %v", err)
+	}
+	out := captureStdout(t, func() {
+		if err := sleepSessionsCmd.RunE(sleepSessionsCmd, []string{}); err != nil {
+			t.Fatalf("sleep sessions: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["id"] != "session-1" || rows[0]["asleep"] != float64(5400) || rows[0]["end"] != "2024-01-02T00:40:00Z" {
+		t.Fatalf("unexpected sessions: %v", rows)
+	}
+}
+
+func TestSleepSessionCommand(t *testing.T) {
+	setupTestEnv(t)
+	viper.Set("temp_unit", "C")
+	out := captureStdout(t, func() {
+		if err := sleepSessionCmd.RunE(sleepSessionCmd, []string{"session-1"}); err != nil {
+			t.Fatalf("sleep session: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 stages, got %v", rows)
+	}
+	if rows[0]["stage"] != "awake" || rows[0]["heart_rate"] != float64(64) || rows[0]["tnt"] != float64(0) {
+		t.Fatalf("unexpected awake row: %v", rows[0])
+	}
+	if rows[1]["heart_rate"] != 57.5 || rows[1]["tnt"] != float64(2) || rows[1]["bed_temp"] != nil {
+		t.Fatalf("unexpected light row: %v", rows[1])
+	}
+	if rows[2]["bed_temp"] != float64(30) || rows[2]["heart_rate"] != nil {
+		t.Fatalf("unexpected deep row: %v", rows[2])
+	}
 }
 
 func TestMetricsCommands(t *testing.T) {
 	setupTestEnv(t)
 	resetFlagsOnCleanup(t, metricsTrendsCmd)
 	resetFlagsOnCleanup(t, metricsIntervalsCmd)
+	// Without --from or --to the API picks the range, as it always has.
+	if err := metricsTrendsCmd.RunE(metricsTrendsCmd, []string{}); err != nil {
+		t.Fatalf("metrics trends without flags: %v", err)
+	}
 	if err := metricsTrendsCmd.Flags().Set("from", "2024-01-01"); err != nil {
 		t.Fatalf("set from: %v", err)
 	}
@@ -108,8 +703,14 @@ func TestMetricsCommands(t *testing.T) {
 	if err := metricsIntervalsCmd.Flags().Set("id", "session-1"); err != nil {
 		t.Fatalf("set id: %v", err)
 	}
-	if err := metricsIntervalsCmd.RunE(metricsIntervalsCmd, []string{}); err != nil {
-		t.Fatalf("metrics intervals: %v", err)
+	out := captureStdout(t, func() {
+		if err := metricsIntervalsCmd.RunE(metricsIntervalsCmd, []string{}); err != nil {
+			t.Fatalf("metrics intervals: %v", err)
+		}
+	})
+	// The payload passes through as the API returned it.
+	if !strings.Contains(out, `"intervals": [`) {
+		t.Fatalf("expected the raw intervals payload, got %s", out)
 	}
 }
 
@@ -124,6 +725,63 @@ func TestDeviceCommands(t *testing.T) {
 			t.Fatalf("device %s: %v", cmd.Use, err)
 		}
 	}
+	for name, want := range map[string]string{"peripherals": "[\n  \"fan\"\n]", "online": "true"} {
+		c, _, err := deviceCmd.Find([]string{name})
+		if err != nil {
+			t.Fatalf("find %s: %v", name, err)
+		}
+		out := captureStdout(t, func() {
+			if err := c.RunE(c, []string{}); err != nil {
+				t.Fatalf("device %s: %v", name, err)
+			}
+		})
+		if out != want {
+			t.Fatalf("device %s = %q, want %q", name, out, want)
+		}
+	}
+}
+
+func TestDeviceInfoCommand(t *testing.T) {
+	setupTestEnv(t)
+	out := captureStdout(t, func() {
+		if err := deviceInfoCmd.RunE(deviceInfoCmd, []string{}); err != nil {
+			t.Fatalf("device info: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+	if rows[0]["model"] != "Pod 4" || rows[0]["right_user_id"] != "uid-456" {
+		t.Fatalf("unexpected device row: %v", rows[0])
+	}
+	if rows[0]["left_target"] != float64(-30) {
+		t.Fatalf("expected left_target -30, got %v", rows[0]["left_target"])
+	}
+
+	resetFlagsOnCleanup(t, deviceInfoCmd)
+	if err := deviceInfoCmd.Flags().Set("raw", "true"); err != nil {
+		t.Fatalf("set raw: %v", err)
+	}
+	out = captureStdout(t, func() {
+		if err := deviceInfoCmd.RunE(deviceInfoCmd, []string{}); err != nil {
+			t.Fatalf("device info --raw: %v", err)
+		}
+	})
+	if !strings.Contains(out, `"leftTargetHeatingLevel": -30`) {
+		t.Fatalf("expected the raw payload, got %s", out)
+	}
+}
+
+func TestDeviceInfoCommandInvalidFields(t *testing.T) {
+	setupTestEnv(t)
+	viper.Set("fields", []string{"nope"})
+	if err := deviceInfoCmd.RunE(deviceInfoCmd, []string{}); err == nil {
+		t.Fatalf("expected fields validation error")
+	}
 }
 
 func TestAlarmListCommand(t *testing.T) {
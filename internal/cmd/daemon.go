@@ -11,6 +11,8 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/salmonumbrella/eightsleep-cli/internal/daemon"
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
 )
 
 var daemonCmd = &cobra.Command{
@@ -21,29 +23,46 @@ var daemonCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		cfgData, err := readConfigSchedule()
+		items, loc, err := loadSchedule()
 		if err != nil {
 			return err
 		}
-		items, err := parseSchedule(cfgData)
+		temps, err := temperatureTable()
 		if err != nil {
 			return err
 		}
-		tzName, err := resolveTimezone(viper.GetString("timezone"))
+		onFailure, err := daemon.ParseFailurePolicy(viper.GetString("on-failure"))
 		if err != nil {
 			return err
 		}
-		loc, err := time.LoadLocation(tzName)
-		if err != nil {
-			return fmt.Errorf("load timezone: %w", err)
+		retries := viper.GetInt("action-retries")
+		if retries < 0 {
+			return fmt.Errorf("--action-retries must be >= 0")
 		}
 		r := daemon.Runner{
-			Items:    items,
-			Client:   cl,
-			Timezone: loc,
-			DryRun:   viper.GetBool("dry-run"),
-			Sync:     viper.GetBool("sync-state"),
-			PIDFile:  defaultPIDFile(viper.GetString("pid-file")),
+			Items:        items,
+			Client:       daemon.ClientController{Client: cl},
+			Timezone:     loc,
+			DryRun:       viper.GetBool("dry-run"),
+			Sync:         viper.GetBool("sync-state"),
+			SyncInterval: viper.GetDuration("sync-interval"),
+			PollInterval: viper.GetDuration("poll-interval"),
+			PIDFile:      defaultPIDFile(viper.GetString("pid-file")),
+			Temps:        temps,
+			Retries:      retries,
+			OnFailure:    onFailure,
+			RetryBackoff: viper.GetDuration("retry-backoff"),
+			Reload:       reloadSchedule,
+			ConfigFile:   viper.ConfigFileUsed(),
+			Socket:       defaultSocketFile(viper.GetString("socket")),
+		}
+		if path := defaultJournalFile(viper.GetString("journal")); path != "" {
+			r.Journal = daemon.NewJournal(path)
+		}
+		if listen := viper.GetString("listen"); listen != "" {
+			r.Listen = listen
+			r.Metrics = daemon.NewMetrics()
+			cl.Observer = r.Metrics
 		}
 		fmt.Printf("daemon started with %d items\n", len(items))
 		// Use cmd.Context() directly instead of requestContext() because daemons
@@ -52,13 +71,238 @@ var daemonCmd = &cobra.Command{
 	},
 }
 
+var daemonPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show upcoming schedule firings",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		items, loc, err := loadSchedule()
+		if err != nil {
+			return err
+		}
+		days, _ := cmd.Flags().GetInt("days")
+		if days <= 0 {
+			return fmt.Errorf("--days must be > 0")
+		}
+		now := time.Now().In(loc)
+		firings, err := daemon.Plan(items, loc, now, now.AddDate(0, 0, days))
+		if err != nil {
+			return err
+		}
+		rows := make([]map[string]any, 0, len(firings))
+		for _, f := range firings {
+			rows = append(rows, map[string]any{
+				"time":        f.At.Format(time.RFC3339),
+				"item":        f.Item.Label(),
+				"action":      f.Item.Action,
+				"temperature": f.Item.Temperature,
+			})
+		}
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, []string{"time", "item", "action", "temperature"}); err != nil {
+			return err
+		}
+		rows = output.FilterFields(rows, fields)
+		headers := []string{"time", "item", "action", "temperature"}
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return printColumns(outputFormat(), headers, rows, output.Columns{"time": output.Timestamp(loc)})
+	},
+}
+
+var daemonHistoryFields = []string{"time", "scheduled", "item", "action", "temperature", "result", "attempts", "error"}
+
+var daemonHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show actions recorded in the daemon journal",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, _ := cmd.Flags().GetDuration("since")
+		if since <= 0 {
+			return fmt.Errorf("--since must be > 0")
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit < 0 {
+			return fmt.Errorf("--limit must be >= 0")
+		}
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, daemonHistoryFields); err != nil {
+			return err
+		}
+		_, loc, err := resolveLocation()
+		if err != nil {
+			return err
+		}
+		j := daemon.NewJournal(defaultJournalFile(viper.GetString("journal")))
+		entries, err := j.Read(time.Now().Add(-since))
+		if err != nil {
+			return err
+		}
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		rows := make([]map[string]any, 0, len(entries))
+		for _, e := range entries {
+			rows = append(rows, map[string]any{
+				"time":        e.Time.Format(time.RFC3339),
+				"scheduled":   e.Scheduled.Format(time.RFC3339),
+				"item":        e.Item,
+				"action":      e.Action,
+				"temperature": e.Temperature,
+				"result":      e.Result,
+				"attempts":    e.Attempts,
+				"error":       e.Error,
+			})
+		}
+		rows = output.FilterFields(rows, fields)
+		headers := daemonHistoryFields
+		if len(fields) > 0 {
+			headers = fields
+		}
+		stamp := output.Timestamp(loc)
+		return printColumns(outputFormat(), headers, rows, output.Columns{"time": stamp, "scheduled": stamp})
+	},
+}
+
+var daemonStatusFields = []string{"running", "pid", "pid_file", "stale"}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is running",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, daemonStatusFields); err != nil {
+			return err
+		}
+		path := defaultPIDFile(viper.GetString("pid-file"))
+		if path == "" {
+			return fmt.Errorf("cannot determine pid file; specify --pid-file")
+		}
+		st, err := daemon.ReadPIDStatus(path)
+		if err != nil {
+			return err
+		}
+		row := map[string]any{
+			"running":  st.Running,
+			"pid":      st.PID,
+			"pid_file": path,
+			"stale":    st.Stale,
+		}
+		rows := output.FilterFields([]map[string]any{row}, fields)
+		headers := daemonStatusFields
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return output.Print(outputFormat(), headers, rows)
+	},
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := defaultPIDFile(viper.GetString("pid-file"))
+		if path == "" {
+			return fmt.Errorf("cannot determine pid file; specify --pid-file")
+		}
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		pid, err := daemon.StopDaemon(path, timeout)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("daemon stopped (pid %d)\n", pid)
+		return nil
+	},
+}
+
 func init() {
+	daemonStopCmd.Flags().Duration("timeout", 10*time.Second, "how long to wait for the daemon to exit")
+	daemonCmd.AddCommand(daemonStatusCmd, daemonStopCmd)
+
+	daemonPlanCmd.Flags().Int("days", 7, "number of days to look ahead")
+	daemonHistoryCmd.Flags().Duration("since", 7*24*time.Hour, "show entries recorded within this window")
+	daemonHistoryCmd.Flags().Int("limit", 0, "show at most this many recent entries (0 = all)")
+	daemonCmd.AddCommand(daemonPlanCmd, daemonHistoryCmd)
+
+	daemonCmd.PersistentFlags().String("journal", "", "journal file path (default ~/.config/eightsleep/daemon-journal.jsonl)")
+	_ = viper.BindPFlag("journal", daemonCmd.PersistentFlags().Lookup("journal"))
+
 	daemonCmd.Flags().Bool("dry-run", false, "log actions without executing")
-	daemonCmd.Flags().Bool("sync-state", false, "(reserved) sync device state")
-	daemonCmd.Flags().String("pid-file", "", "pid file path (default ~/.config/eightsleep-cli/daemon.pid)")
+	daemonCmd.Flags().Bool("sync-state", false, "periodically re-apply the scheduled state if the device drifted")
+	daemonCmd.Flags().Duration("sync-interval", daemon.DefaultSyncInterval, "how often --sync-state checks the device")
+	daemonCmd.PersistentFlags().String("pid-file", "", "pid file path (default ~/.config/eightsleep/daemon.pid)")
 	_ = viper.BindPFlag("dry-run", daemonCmd.Flags().Lookup("dry-run"))
 	_ = viper.BindPFlag("sync-state", daemonCmd.Flags().Lookup("sync-state"))
-	_ = viper.BindPFlag("pid-file", daemonCmd.Flags().Lookup("pid-file"))
+	_ = viper.BindPFlag("sync-interval", daemonCmd.Flags().Lookup("sync-interval"))
+	daemonCmd.Flags().Duration("poll-interval", daemon.DefaultPollInterval, "how often sleep data is read for items with a when condition, and the bed level for --listen metrics")
+	_ = viper.BindPFlag("poll-interval", daemonCmd.Flags().Lookup("poll-interval"))
+	daemonCmd.Flags().String("listen", "", "serve /healthz and Prometheus /metrics on this address, e.g. 127.0.0.1:9477")
+	_ = viper.BindPFlag("listen", daemonCmd.Flags().Lookup("listen"))
+	daemonCmd.Flags().Int("action-retries", 3, "retries per failed action, on top of --retries for each API call")
+	daemonCmd.Flags().Duration("retry-backoff", daemon.DefaultRetryBackoff, "delay before the first retry, doubling each attempt")
+	daemonCmd.Flags().String("on-failure", daemon.FailureContinue, "after retries are exhausted: continue, exit or \"exit-after N\" consecutive failures")
+	_ = viper.BindPFlag("pid-file", daemonCmd.PersistentFlags().Lookup("pid-file"))
+	_ = viper.BindPFlag("action-retries", daemonCmd.Flags().Lookup("action-retries"))
+	_ = viper.BindPFlag("retry-backoff", daemonCmd.Flags().Lookup("retry-backoff"))
+	_ = viper.BindPFlag("on-failure", daemonCmd.Flags().Lookup("on-failure"))
+}
+
+// loadSchedule reads and validates the schedule, with profiles expanded, from
+// the config file in the configured timezone.
+func loadSchedule() ([]daemon.ScheduleItem, *time.Location, error) {
+	cfgData, err := readConfigSchedule()
+	if err != nil {
+		return nil, nil, err
+	}
+	temps, err := temperatureTable()
+	if err != nil {
+		return nil, nil, err
+	}
+	items, err := parseSchedule(cfgData, temps)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := scheduleLocation()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := daemon.ValidateSchedule(items, loc); err != nil {
+		return nil, nil, err
+	}
+	return items, loc, nil
+}
+
+// reloadSchedule re-reads the config file, so a changed timezone or
+// temp_calibration applies along with the schedule, and loads it again.
+func reloadSchedule() (daemon.ScheduleConfig, error) {
+	viper.SetConfigType("yaml")
+	if err := viper.ReadInConfig(); err != nil {
+		return daemon.ScheduleConfig{}, fmt.Errorf("read config: %w", err)
+	}
+	items, loc, err := loadSchedule()
+	if err != nil {
+		return daemon.ScheduleConfig{}, err
+	}
+	temps, err := temperatureTable()
+	if err != nil {
+		return daemon.ScheduleConfig{}, err
+	}
+	return daemon.ScheduleConfig{Items: items, Timezone: loc, Temps: temps}, nil
+}
+
+func scheduleLocation() (*time.Location, error) {
+	tzName, err := resolveTimezone(viper.GetString("timezone"))
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(tzName)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone: %w", err)
+	}
+	return loc, nil
 }
 
 func readConfigSchedule() ([]byte, error) {
@@ -69,17 +313,34 @@ func readConfigSchedule() ([]byte, error) {
 	return os.ReadFile(cfg)
 }
 
-func parseSchedule(data []byte) ([]daemon.ScheduleItem, error) {
-	var raw struct {
-		Schedule []daemon.ScheduleItem `yaml:"schedule"`
-	}
+// scheduleConfig is the part of the config file the daemon reads.
+type scheduleConfig struct {
+	Schedule []daemon.ScheduleItem `yaml:"schedule"`
+	Profiles []daemon.Profile      `yaml:"profiles"`
+}
+
+func parseScheduleConfig(data []byte) (scheduleConfig, error) {
+	var raw scheduleConfig
 	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return scheduleConfig{}, err
+	}
+	return raw, nil
+}
+
+// parseSchedule returns the schedule items followed by the expanded profiles.
+func parseSchedule(data []byte, temps tempconv.Table) ([]daemon.ScheduleItem, error) {
+	raw, err := parseScheduleConfig(data)
+	if err != nil {
 		return nil, err
 	}
-	if len(raw.Schedule) == 0 {
-		return nil, fmt.Errorf("no schedule entries found")
+	if len(raw.Schedule) == 0 && len(raw.Profiles) == 0 {
+		return nil, fmt.Errorf("no schedule entries or profiles found")
+	}
+	expanded, err := daemon.ExpandProfiles(raw.Profiles, temps)
+	if err != nil {
+		return nil, err
 	}
-	return raw.Schedule, nil
+	return append(raw.Schedule, expanded...), nil
 }
 
 func defaultPIDFile(flagValue string) string {
@@ -92,3 +353,14 @@ func defaultPIDFile(flagValue string) string {
 	}
 	return filepath.Join(home, ".config", "eightsleep", "daemon.pid")
 }
+
+func defaultJournalFile(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "eightsleep", "daemon-journal.jsonl")
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/daemon"
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+)
+
+var daemonControlFields = []string{"next_time", "next_item", "next_action", "next_temperature", "paused_until", "result"}
+
+var daemonNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Ask the running daemon for its next action",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendDaemonControl(cmd, daemon.ControlRequest{Command: daemon.ControlNext})
+	},
+}
+
+var daemonPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause the running daemon's schedule (default: for tonight, until noon)",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := daemon.ControlRequest{Command: daemon.ControlPause}
+		until, _ := cmd.Flags().GetString("until")
+		if until != "" {
+			t, err := parseControlTime(until)
+			if err != nil {
+				return fmt.Errorf("--until: %w", err)
+			}
+			req.Until = t
+		}
+		return sendDaemonControl(cmd, req)
+	},
+}
+
+var daemonResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume a paused schedule",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendDaemonControl(cmd, daemon.ControlRequest{Command: daemon.ControlResume})
+	},
+}
+
+var daemonRunCmd = &cobra.Command{
+	Use:   "run <action> [temperature]",
+	Short: "Have the running daemon perform a one-time action",
+	Long: `Have the running daemon perform a one-time action with its authenticated
+client, now or at --at. Actions are the schedule's: on, off, temp, nap-on,
+nap-off, hotflash-on and hotflash-off. One-time actions run even while
+the schedule is paused. An immediate action is tried once and its result
+reported here; it is not retried.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := daemon.ControlRequest{Command: daemon.ControlRun, Action: args[0]}
+		if len(args) == 2 {
+			req.Temperature = args[1]
+		}
+		duration, _ := cmd.Flags().GetDuration("duration")
+		if duration < 0 {
+			return fmt.Errorf("--duration must be >= 0")
+		}
+		if duration > 0 {
+			req.Duration = duration.String()
+		}
+		at, _ := cmd.Flags().GetString("at")
+		if at != "" {
+			t, err := parseControlTime(at)
+			if err != nil {
+				return fmt.Errorf("--at: %w", err)
+			}
+			req.At = t
+		}
+		return sendDaemonControl(cmd, req)
+	},
+}
+
+func sendDaemonControl(cmd *cobra.Command, req daemon.ControlRequest) error {
+	fields := viper.GetStringSlice("fields")
+	if err := validateFields(fields, daemonControlFields); err != nil {
+		return err
+	}
+	socket := defaultSocketFile(viper.GetString("socket"))
+	if socket == "" {
+		return fmt.Errorf("cannot determine control socket; specify --socket")
+	}
+	ctx, cancel, err := requestContext(cmd)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	res, err := daemon.SendControl(ctx, socket, req)
+	if err != nil {
+		return err
+	}
+	row := map[string]any{
+		"next_time":        "",
+		"next_item":        "",
+		"next_action":      "",
+		"next_temperature": "",
+		"paused_until":     "",
+		"result":           res.Result,
+	}
+	if res.Next != nil {
+		row["next_time"] = res.Next.At.Format(time.RFC3339)
+		row["next_item"] = res.Next.Item
+		row["next_action"] = res.Next.Action
+		row["next_temperature"] = res.Next.Temperature
+	}
+	if !res.PausedUntil.IsZero() {
+		row["paused_until"] = res.PausedUntil.Format(time.RFC3339)
+	}
+	rows := output.FilterFields([]map[string]any{row}, fields)
+	headers := daemonControlFields
+	if len(fields) > 0 {
+		headers = fields
+	}
+	return output.Print(outputFormat(), headers, rows)
+}
+
+// parseControlTime accepts RFC3339 or a wall-clock HH:MM, which means its
+// next occurrence in the configured timezone.
+func parseControlTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	clock, err := time.Parse("15:04", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected HH:MM or RFC3339, got %q", s)
+	}
+	tzName, err := resolveTimezone(viper.GetString("timezone"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(tzName)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("load timezone: %w", err)
+	}
+	now := time.Now().In(loc)
+	t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// defaultSocketFile places the control socket next to the PID file.
+func defaultSocketFile(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	pid := defaultPIDFile(viper.GetString("pid-file"))
+	if pid == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(pid), "daemon.sock")
+}
+
+func init() {
+	daemonPauseCmd.Flags().String("until", "", "resume at this time (HH:MM or RFC3339)")
+	daemonRunCmd.Flags().String("at", "", "run at this time instead of now (HH:MM or RFC3339)")
+	daemonRunCmd.Flags().Duration("duration", 0, "limit a temp action (e.g., 2h, 90m; default indefinite)")
+	daemonCmd.AddCommand(daemonNextCmd, daemonPauseCmd, daemonResumeCmd, daemonRunCmd)
+
+	daemonCmd.PersistentFlags().String("socket", "", "control socket path (default: daemon.sock next to the pid file)")
+	_ = viper.BindPFlag("socket", daemonCmd.PersistentFlags().Lookup("socket"))
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/daemon"
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+)
+
+var daemonTimelineFields = []string{"time", "profile", "phase", "action", "level", "temperature"}
+
+var daemonTimelineCmd = &cobra.Command{
+	Use:   "timeline [profile...]",
+	Short: "Preview the temperature curve of sleep profiles for one night",
+	Long: `Preview the steps each sleep profile will take on its next night (or the
+night starting on --date), without touching the device.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, daemonTimelineFields); err != nil {
+			return err
+		}
+		data, err := readConfigSchedule()
+		if err != nil {
+			return err
+		}
+		raw, err := parseScheduleConfig(data)
+		if err != nil {
+			return err
+		}
+		temps, err := temperatureTable()
+		if err != nil {
+			return err
+		}
+		unit, err := temperatureUnit()
+		if err != nil {
+			return err
+		}
+		loc, err := scheduleLocation()
+		if err != nil {
+			return err
+		}
+		after := time.Now().In(loc)
+		if date, _ := cmd.Flags().GetString("date"); date != "" {
+			span, err := parseDateArg(date, after)
+			if err != nil {
+				return fmt.Errorf("--date: %w", err)
+			}
+			if span.period {
+				return fmt.Errorf("--date: %q names several days", date)
+			}
+			after = span.start.Add(-time.Nanosecond)
+		}
+		profiles, err := selectProfiles(raw.Profiles, args)
+		if err != nil {
+			return err
+		}
+		format := outputFormat()
+		rows := []map[string]any{}
+		for _, p := range profiles {
+			items, err := daemon.ExpandProfiles([]daemon.Profile{p}, temps)
+			if err != nil {
+				return err
+			}
+			start, err := p.NextStart(loc, after)
+			if err != nil {
+				return err
+			}
+			if start.IsZero() {
+				continue
+			}
+			// Plan the expanded items, as the daemon runs them, up to the next
+			// start: steps keep their wall-clock time across a DST change.
+			next := start.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			firings, err := daemon.Plan(items, loc, start.Add(-time.Nanosecond), next)
+			if err != nil {
+				return err
+			}
+			for _, f := range firings {
+				row := map[string]any{
+					"time":        f.At.Format(time.RFC3339),
+					"profile":     p.Name,
+					"phase":       strings.TrimPrefix(f.Item.Name, p.Name+"/"),
+					"action":      f.Item.Action,
+					"level":       "",
+					"temperature": "",
+				}
+				if f.Item.Action == "temp" {
+					level, err := strconv.Atoi(f.Item.Temperature)
+					if err != nil {
+						return fmt.Errorf("profile %s: level %q: %w", p.Name, f.Item.Temperature, err)
+					}
+					row["level"] = level
+					row["temperature"] = displayTemp(format, temps.ToUnit(level, unit), unit)
+				}
+				rows = append(rows, row)
+			}
+		}
+		rows = output.FilterFields(rows, fields)
+		headers := daemonTimelineFields
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return output.Print(format, headers, rows)
+	},
+}
+
+// selectProfiles returns the named profiles in config order, or all of them.
+func selectProfiles(profiles []daemon.Profile, names []string) ([]daemon.Profile, error) {
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no profiles found in config")
+	}
+	if len(names) == 0 {
+		return profiles, nil
+	}
+	byName := map[string]daemon.Profile{}
+	for _, p := range profiles {
+		byName[p.Name] = p
+	}
+	out := make([]daemon.Profile, 0, len(names))
+	for _, n := range names {
+		p, ok := byName[n]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", n)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func init() {
+	daemonTimelineCmd.Flags().String("date", "", "preview the night starting on this date: YYYY-MM-DD, today, yesterday, -3d (default: next night)")
+	daemonCmd.AddCommand(daemonTimelineCmd)
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/daemon"
+)
+
+// runServiceCommand runs systemctl/launchctl; tests replace it.
+var runServiceCommand = func(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install --user [-- daemon flags...]",
+	Short: "Install the daemon as a user service (systemd on Linux, launchd on macOS)",
+	Long: `Install the daemon as a user service. The current --config, --account and pid
+file are baked into the service; flags after -- are passed to the daemon, e.g.
+
+  eightsleep daemon install --user -- --sync-state --listen 127.0.0.1:9477
+
+Credentials are never written to the service file: use --account (keyring) or
+the config file. Use --print to see the file without installing it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := serviceManager(cmd)
+		if err != nil {
+			return err
+		}
+		spec, err := daemonServiceSpec(args)
+		if err != nil {
+			return err
+		}
+		out, err := daemon.RenderService(manager, spec)
+		if err != nil {
+			return err
+		}
+		if printOnly, _ := cmd.Flags().GetBool("print"); printOnly {
+			fmt.Print(out)
+			return nil
+		}
+		path, err := serviceFilePath(manager)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", path)
+		if noStart, _ := cmd.Flags().GetBool("no-start"); noStart {
+			return nil
+		}
+		switch manager {
+		case daemon.ServiceSystemd:
+			if err := runServiceCommand("systemctl", "--user", "daemon-reload"); err != nil {
+				return err
+			}
+			return runServiceCommand("systemctl", "--user", "enable", "--now", daemon.SystemdUnitName)
+		default:
+			// Reloading picks up a changed plist when the agent is already loaded.
+			_ = runServiceCommand("launchctl", "unload", path)
+			return runServiceCommand("launchctl", "load", "-w", path)
+		}
+	},
+}
+
+var daemonUninstallCmd = &cobra.Command{
+	Use:   "uninstall --user",
+	Short: "Stop the daemon user service and remove its service file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := serviceManager(cmd)
+		if err != nil {
+			return err
+		}
+		path, err := serviceFilePath(manager)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no service installed at %s", path)
+		}
+		switch manager {
+		case daemon.ServiceSystemd:
+			if err := runServiceCommand("systemctl", "--user", "disable", "--now", daemon.SystemdUnitName); err != nil {
+				return err
+			}
+		default:
+			if err := runServiceCommand("launchctl", "unload", "-w", path); err != nil {
+				return err
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		if manager == daemon.ServiceSystemd {
+			if err := runServiceCommand("systemctl", "--user", "daemon-reload"); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("removed %s\n", path)
+		return nil
+	},
+}
+
+// serviceManager picks the manager from --manager or the OS, and insists on
+// --user: system-wide services are not supported.
+func serviceManager(cmd *cobra.Command) (string, error) {
+	if user, _ := cmd.Flags().GetBool("user"); !user {
+		return "", fmt.Errorf("only user services are supported; pass --user")
+	}
+	if m, _ := cmd.Flags().GetString("manager"); m != "" {
+		return m, nil
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return daemon.ServiceSystemd, nil
+	case "darwin":
+		return daemon.ServiceLaunchd, nil
+	default:
+		return "", fmt.Errorf("no supported service manager on %s; use --manager %s or %s with --print", runtime.GOOS, daemon.ServiceSystemd, daemon.ServiceLaunchd)
+	}
+}
+
+func serviceFilePath(manager string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home: %w", err)
+	}
+	return daemon.ServiceFile(manager, home)
+}
+
+// daemonServiceSpec builds the daemon command line from this invocation.
+func daemonServiceSpec(extra []string) (daemon.ServiceSpec, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return daemon.ServiceSpec{}, fmt.Errorf("find executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	cfg := viper.ConfigFileUsed()
+	if cfg == "" {
+		return daemon.ServiceSpec{}, fmt.Errorf("no config file loaded; specify --config")
+	}
+	if cfg, err = filepath.Abs(cfg); err != nil {
+		return daemon.ServiceSpec{}, err
+	}
+	pid := defaultPIDFile(viper.GetString("pid-file"))
+	if pid == "" {
+		return daemon.ServiceSpec{}, fmt.Errorf("cannot determine pid file; specify --pid-file")
+	}
+	args := []string{exe, "daemon", "--quiet", "--config", cfg, "--pid-file", pid}
+	if account := viper.GetString("account"); account != "" {
+		args = append(args, "--account", account)
+	}
+	for _, key := range []string{"journal", "socket"} {
+		if v := viper.GetString(key); v != "" {
+			args = append(args, "--"+key, v)
+		}
+	}
+	for _, a := range extra {
+		if a == "--password" || strings.HasPrefix(a, "--password=") {
+			return daemon.ServiceSpec{}, fmt.Errorf("refusing to write --password into a service file; use --account")
+		}
+	}
+	args = append(args, extra...)
+	spec := daemon.ServiceSpec{Args: args}
+	if home, err := os.UserHomeDir(); err == nil {
+		spec.LogFile = filepath.Join(home, "Library", "Logs", "eightsleep-daemon.log")
+	}
+	return spec, nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{daemonInstallCmd, daemonUninstallCmd} {
+		c.Flags().Bool("user", false, "install for the current user (required)")
+		c.Flags().String("manager", "", "service manager: systemd or launchd (default: by OS)")
+	}
+	daemonInstallCmd.Flags().Bool("print", false, "print the service file instead of installing it")
+	daemonInstallCmd.Flags().Bool("no-start", false, "write the service file without enabling or starting it")
+	daemonCmd.AddCommand(daemonInstallCmd, daemonUninstallCmd)
+}
@@ -1,6 +1,22 @@
 package cmd
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/daemon"
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
+)
 
 func TestParseSchedule(t *testing.T) {
 	data := []byte(`
@@ -9,7 +25,7 @@ schedule:
     action: "temp"
     temperature: "68F"
 `)
-	items, err := parseSchedule(data)
+	items, err := parseSchedule(data, tempconv.DefaultTable)
 	if err != nil {
 		t.Fatalf("parseSchedule: %v", err)
 	}
@@ -19,7 +35,7 @@ schedule:
 }
 
 func TestParseScheduleEmpty(t *testing.T) {
-	if _, err := parseSchedule([]byte(`schedule: []`)); err == nil {
+	if _, err := parseSchedule([]byte(`schedule: []`), tempconv.DefaultTable); err == nil {
 		t.Fatalf("expected error for empty schedule")
 	}
 }
@@ -29,3 +45,420 @@ func TestDefaultPIDFile(t *testing.T) {
 		t.Fatalf("expected explicit pid path")
 	}
 }
+
+func TestDaemonPlanCommand(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, daemonPlanCmd)
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte(`
+schedule:
+  - name: bedtime
+    time: "22:00"
+    action: temp
+    temperature: "68F"
+  - cron: "0 7 * * *"
+    action: "off"
+`)
+	if err := os.WriteFile(cfg, data, 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	viper.SetConfigFile(cfg)
+	if err := daemonPlanCmd.Flags().Set("days", "2"); err != nil {
+		t.Fatalf("set days: %v", err)
+	}
+	out := captureStdout(t, func() {
+		if err := daemonPlanCmd.RunE(daemonPlanCmd, []string{}); err != nil {
+			t.Fatalf("daemon plan: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 firings over 2 days, got %d", len(rows))
+	}
+	labels := map[any]bool{}
+	for _, r := range rows {
+		labels[r["item"]] = true
+	}
+	if !labels["bedtime"] || !labels["0 7 * * * off"] {
+		t.Fatalf("unexpected plan labels: %v", labels)
+	}
+
+	viper.Set("output", "table")
+	viper.Set("fields", []string{"time", "item"})
+	table := captureStdout(t, func() {
+		if err := daemonPlanCmd.RunE(daemonPlanCmd, []string{}); err != nil {
+			t.Fatalf("daemon plan: %v", err)
+		}
+	})
+	if !strings.Contains(table, " 22:00  bedtime") {
+		t.Fatalf("expected readable timestamps in table, got %q", table)
+	}
+}
+
+func TestDaemonHistoryCommand(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, daemonHistoryCmd)
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	viper.Set("journal", path)
+	t.Cleanup(func() { viper.Set("journal", "") })
+	j := daemon.NewJournal(path)
+	now := time.Now()
+	entries := []daemon.JournalEntry{
+		{Time: now.Add(-72 * time.Hour), Item: "old", Action: "on", Result: daemon.ResultOK},
+		{Time: now.Add(-2 * time.Hour), Item: "bedtime", Action: "temp", Temperature: "68F", Result: daemon.ResultOK},
+		{Time: now.Add(-time.Hour), Item: "wake", Action: "off", Result: daemon.ResultError, Error: "timeout"},
+	}
+	for _, e := range entries {
+		if err := j.Append(e); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	if err := daemonHistoryCmd.Flags().Set("since", "24h"); err != nil {
+		t.Fatalf("set since: %v", err)
+	}
+	out := captureStdout(t, func() {
+		if err := daemonHistoryCmd.RunE(daemonHistoryCmd, []string{}); err != nil {
+			t.Fatalf("daemon history: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["item"] != "bedtime" || rows[1]["error"] != "timeout" {
+		t.Fatalf("unexpected history rows: %v", rows)
+	}
+
+	viper.Set("output", "table")
+	viper.Set("fields", []string{"time", "item"})
+	out = captureStdout(t, func() {
+		if err := daemonHistoryCmd.RunE(daemonHistoryCmd, []string{}); err != nil {
+			t.Fatalf("daemon history: %v", err)
+		}
+	})
+	if want := entries[1].Time.UTC().Format("2006-01-02 15:04"); !strings.Contains(out, want+"  bedtime") {
+		t.Fatalf("expected %q timestamp in table, got %q", want, out)
+	}
+}
+
+func TestDaemonStatusStalePID(t *testing.T) {
+	setupTestEnv(t)
+	path := filepath.Join(t.TempDir(), "daemon.pid")
+	if err := os.WriteFile(path, []byte("999999"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("pid-file", path)
+	t.Cleanup(func() { viper.Set("pid-file", "") })
+	out := captureStdout(t, func() {
+		if err := daemonStatusCmd.RunE(daemonStatusCmd, []string{}); err != nil {
+			t.Fatalf("daemon status: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["running"] != false || rows[0]["stale"] != true || rows[0]["pid"] != float64(999999) {
+		t.Fatalf("unexpected status: %v", rows)
+	}
+	if err := daemonStopCmd.RunE(daemonStopCmd, []string{}); err == nil {
+		t.Fatalf("expected stop to fail when daemon is not running")
+	}
+}
+
+func TestDaemonFlagsKeepGlobalRetries(t *testing.T) {
+	// A local --retries would shadow the global HTTP retry flag and steal its viper key.
+	if daemonCmd.LocalFlags().Lookup("retries") != nil {
+		t.Fatalf("daemon must not define its own --retries")
+	}
+	if daemonCmd.Flags().Lookup("action-retries") == nil {
+		t.Fatalf("expected --action-retries on daemon")
+	}
+}
+
+func TestDaemonControlCommands(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, daemonRunCmd)
+	dir, err := os.MkdirTemp("", "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "d.sock")
+	viper.Set("socket", socket)
+	t.Cleanup(func() { viper.Set("socket", "") })
+
+	if err := daemonNextCmd.RunE(daemonNextCmd, []string{}); !errors.Is(err, daemon.ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning without a daemon, got %v", err)
+	}
+
+	cl, err := requireClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &daemon.Runner{
+		Items:    []daemon.ScheduleItem{{Name: "bedtime", Time: "22:00", Action: "on"}},
+		Client:   daemon.ClientController{Client: cl},
+		Timezone: time.UTC,
+		Socket:   socket,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("run: %v", err)
+		}
+	})
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(socket); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	run := func(c *cobra.Command, args ...string) map[string]any {
+		t.Helper()
+		out := captureStdout(t, func() {
+			if err := c.RunE(c, args); err != nil {
+				t.Fatalf("%s: %v", c.Name(), err)
+			}
+		})
+		var rows []map[string]any
+		if err := json.Unmarshal([]byte(out), &rows); err != nil || len(rows) != 1 {
+			t.Fatalf("%s: parse %q: %v", c.Name(), out, err)
+		}
+		return rows[0]
+	}
+	if row := run(daemonNextCmd); row["next_item"] != "bedtime" {
+		t.Fatalf("unexpected next: %v", row)
+	}
+	if row := run(daemonPauseCmd); row["paused_until"] == "" || row["next_item"] != "bedtime" {
+		t.Fatalf("unexpected pause: %v", row)
+	}
+	if row := run(daemonRunCmd, "on"); row["result"] != daemon.ResultOK {
+		t.Fatalf("unexpected run: %v", row)
+	}
+	if err := daemonRunCmd.Flags().Set("duration", "soon"); err == nil {
+		t.Fatalf("expected --duration to reject %q", "soon")
+	}
+	if err := daemonRunCmd.Flags().Set("duration", "90m"); err != nil {
+		t.Fatalf("set duration: %v", err)
+	}
+	if row := run(daemonRunCmd, "temp", "-20"); row["result"] != daemon.ResultOK {
+		t.Fatalf("unexpected timed run: %v", row)
+	}
+	if err := daemonRunCmd.Flags().Set("duration", "-1h"); err != nil {
+		t.Fatalf("set duration: %v", err)
+	}
+	if err := daemonRunCmd.RunE(daemonRunCmd, []string{"temp", "-20"}); err == nil {
+		t.Fatalf("expected negative duration error")
+	}
+	if row := run(daemonResumeCmd); row["paused_until"] != "" {
+		t.Fatalf("unexpected resume: %v", row)
+	}
+}
+
+func TestDaemonTimelineCommand(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, daemonTimelineCmd)
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte(`
+timezone: UTC
+profiles:
+  - name: night
+    start: "22:00"
+    step: 15m
+    end: 8h
+    phases:
+      - name: bedtime
+        temperature: "-20"
+      - name: early-sleep
+        at: 30m
+        temperature: "-40"
+        ramp: 30m
+`)
+	if err := os.WriteFile(cfg, data, 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	viper.SetConfigFile(cfg)
+	viper.Set("timezone", "UTC")
+	t.Cleanup(func() { viper.Set("timezone", "") })
+	if err := daemonTimelineCmd.Flags().Set("date", "2024-03-01"); err != nil {
+		t.Fatalf("set date: %v", err)
+	}
+	out := captureStdout(t, func() {
+		if err := daemonTimelineCmd.RunE(daemonTimelineCmd, []string{"night"}); err != nil {
+			t.Fatalf("daemon timeline: %v", err)
+		}
+	})
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	var got []string
+	for _, r := range rows {
+		got = append(got, fmt.Sprintf("%s %s %v", r["time"], r["action"], r["level"]))
+	}
+	want := []string{
+		"2024-03-01T22:00:00Z on ",
+		"2024-03-01T22:00:00Z temp -20",
+		"2024-03-01T22:45:00Z temp -30",
+		"2024-03-01T23:00:00Z temp -40",
+		"2024-03-02T06:00:00Z off ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected timeline:\n%s", strings.Join(got, "\n"))
+	}
+
+	items, err := parseSchedule(data, tempconv.DefaultTable)
+	if err != nil {
+		t.Fatalf("parseSchedule with profiles only: %v", err)
+	}
+	if len(items) != len(want) || items[0].Label() != "night/bedtime" {
+		t.Fatalf("unexpected expanded items: %+v", items)
+	}
+	if err := daemonTimelineCmd.RunE(daemonTimelineCmd, []string{"missing"}); err == nil {
+		t.Fatalf("expected unknown profile error")
+	}
+
+	// Clocks spring forward overnight; steps fire at wall-clock times.
+	viper.Set("timezone", "America/New_York")
+	if err := daemonTimelineCmd.Flags().Set("date", "2024-03-09"); err != nil {
+		t.Fatalf("set date: %v", err)
+	}
+	out = captureStdout(t, func() {
+		if err := daemonTimelineCmd.RunE(daemonTimelineCmd, []string{"night"}); err != nil {
+			t.Fatalf("daemon timeline: %v", err)
+		}
+	})
+	if !strings.Contains(out, `"time": "2024-03-10T06:00:00-04:00"`) || !strings.Contains(out, `"phase": "end"`) {
+		t.Fatalf("expected the end step at 06:00 local:\n%s", out)
+	}
+
+	if err := daemonTimelineCmd.Flags().Set("date", "yesterday"); err != nil {
+		t.Fatalf("set date: %v", err)
+	}
+	captureStdout(t, func() {
+		if err := daemonTimelineCmd.RunE(daemonTimelineCmd, []string{"night"}); err != nil {
+			t.Fatalf("relative --date: %v", err)
+		}
+	})
+	if err := daemonTimelineCmd.Flags().Set("date", "last-week"); err != nil {
+		t.Fatalf("set date: %v", err)
+	}
+	if err := daemonTimelineCmd.RunE(daemonTimelineCmd, []string{"night"}); err == nil {
+		t.Fatalf("expected an error for a multi-day --date")
+	}
+}
+
+func TestDaemonInstallAndUninstall(t *testing.T) {
+	setupTestEnv(t)
+	resetFlagsOnCleanup(t, daemonInstallCmd)
+	resetFlagsOnCleanup(t, daemonUninstallCmd)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cfg := filepath.Join(home, "config.yaml")
+	if err := os.WriteFile(cfg, []byte("schedule: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(cfg)
+	viper.Set("account", "bedroom")
+	t.Cleanup(func() { viper.Set("account", "") })
+	var ran []string
+	orig := runServiceCommand
+	runServiceCommand = func(name string, args ...string) error {
+		ran = append(ran, name+" "+strings.Join(args, " "))
+		return nil
+	}
+	t.Cleanup(func() { runServiceCommand = orig })
+
+	for _, c := range []*cobra.Command{daemonInstallCmd, daemonUninstallCmd} {
+		if err := c.Flags().Set("manager", daemon.ServiceSystemd); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.RunE(c, nil); err == nil || !strings.Contains(err.Error(), "--user") {
+			t.Fatalf("%s: expected --user to be required, got %v", c.Name(), err)
+		}
+		if err := c.Flags().Set("user", "true"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := daemonInstallCmd.RunE(daemonInstallCmd, []string{"--password=secret"}); err == nil {
+		t.Fatalf("expected --password to be refused")
+	}
+	captureStdout(t, func() {
+		if err := daemonInstallCmd.RunE(daemonInstallCmd, []string{"--sync-state"}); err != nil {
+			t.Fatalf("install: %v", err)
+		}
+	})
+	unit := filepath.Join(home, ".config", "systemd", "user", daemon.SystemdUnitName)
+	data, err := os.ReadFile(unit)
+	if err != nil {
+		t.Fatalf("read unit: %v", err)
+	}
+	for _, want := range []string{`"--config" "` + cfg + `"`, `"--account" "bedroom"`, `"--pid-file" "` + filepath.Join(home, ".config", "eightsleep", "daemon.pid") + `"`, `"--sync-state"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("unit missing %s:\n%s", want, data)
+		}
+	}
+	captureStdout(t, func() {
+		if err := daemonUninstallCmd.RunE(daemonUninstallCmd, nil); err != nil {
+			t.Fatalf("uninstall: %v", err)
+		}
+	})
+	if _, err := os.Stat(unit); !os.IsNotExist(err) {
+		t.Fatalf("expected unit to be removed, got %v", err)
+	}
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable --now " + daemon.SystemdUnitName,
+		"systemctl --user disable --now " + daemon.SystemdUnitName,
+		"systemctl --user daemon-reload",
+	}
+	if strings.Join(ran, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected service commands:\n%s", strings.Join(ran, "\n"))
+	}
+	if err := daemonUninstallCmd.RunE(daemonUninstallCmd, nil); err == nil {
+		t.Fatalf("expected error uninstalling twice")
+	}
+}
+
+func TestReloadScheduleRereadsConfig(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(cfg, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("timezone: UTC\nschedule:\n  - time: \"22:00\"\n    action: \"on\"\n")
+	viper.SetConfigFile(cfg)
+	got, err := reloadSchedule()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got.Timezone.String() != "UTC" || len(got.Items) != 1 {
+		t.Fatalf("unexpected schedule: %+v", got)
+	}
+	if _, err := time.LoadLocation("America/New_York"); err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	write("timezone: America/New_York\ntemp_calibration:\n  - {level: -100, fahrenheit: 60}\n  - {level: 100, fahrenheit: 100}\nschedule:\n  - time: \"22:00\"\n    action: \"on\"\n")
+	got, err = reloadSchedule()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got.Timezone.String() != "America/New_York" {
+		t.Fatalf("expected the edited timezone, got %s", got.Timezone)
+	}
+	if len(got.Temps) != 2 || got.Temps[0].Fahrenheit != 60 {
+		t.Fatalf("expected the edited calibration, got %+v", got.Temps)
+	}
+}
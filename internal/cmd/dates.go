@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateHelp lists the forms every date flag accepts.
+const dateHelp = "YYYY-MM-DD, today, yesterday, last-night, -7d, -2w, this-week, last-week, this-month, last-month, YYYY-MM or YYYY-Www"
+
+const dayLayout = "2006-01-02"
+
+var (
+	relativeDayRe = regexp.MustCompile(`^-(\d+)([dw])$`)
+	isoWeekRe     = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+	monthRe       = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+)
+
+// dateSpan is the inclusive range of days a date argument names.
+type dateSpan struct {
+	start time.Time
+	end   time.Time
+	// period is set for weeks and months, which --date cannot take.
+	period bool
+}
+
+// parseDateArg resolves raw relative to now, whose location decides what
+// "today" is. last-night is today: a night is dated by the morning it ends
+// on, like sleep day. this-week and this-month stop at today.
+func parseDateArg(raw string, now time.Time) (dateSpan, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	day := func(t time.Time) dateSpan { return dateSpan{start: t, end: t} }
+	period := func(start, end time.Time) dateSpan {
+		if end.After(today) {
+			end = today
+		}
+		return dateSpan{start: start, end: end, period: true}
+	}
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	firstOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc)
+	switch s {
+	case "today", "last-night":
+		return day(today), nil
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1)), nil
+	case "this-week":
+		return period(monday, monday.AddDate(0, 0, 6)), nil
+	case "last-week":
+		return period(monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1)), nil
+	case "this-month":
+		return period(firstOfMonth, firstOfMonth.AddDate(0, 1, -1)), nil
+	case "last-month":
+		return period(firstOfMonth.AddDate(0, -1, 0), firstOfMonth.AddDate(0, 0, -1)), nil
+	}
+	if m := relativeDayRe.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return dateSpan{}, fmt.Errorf("invalid date %q: %w", raw, err)
+		}
+		if m[2] == "w" {
+			n *= 7
+		}
+		return day(today.AddDate(0, 0, -n)), nil
+	}
+	if m := isoWeekRe.FindStringSubmatch(strings.ToUpper(s)); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		// January 4th is always in ISO week 1.
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+		start := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+		if y, w := start.ISOWeek(); week < 1 || y != year || w != week {
+			return dateSpan{}, fmt.Errorf("invalid date %q: %d has no week %d", raw, year, week)
+		}
+		return period(start, start.AddDate(0, 0, 6)), nil
+	}
+	if m := monthRe.FindStringSubmatch(s); m != nil {
+		start, err := time.ParseInLocation("2006-01", s, loc)
+		if err != nil {
+			return dateSpan{}, fmt.Errorf("invalid date %q: %w", raw, err)
+		}
+		return period(start, start.AddDate(0, 1, -1)), nil
+	}
+	t, err := time.ParseInLocation(dayLayout, s, loc)
+	if err != nil {
+		return dateSpan{}, fmt.Errorf("invalid date %q (want %s)", raw, dateHelp)
+	}
+	return day(t), nil
+}
+
+// dateNow is the current time in the configured --timezone.
+func dateNow() (time.Time, error) {
+	_, loc, err := resolveLocation()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().In(loc), nil
+}
+
+// resolveDay resolves a single-day flag such as --date; empty means today.
+func resolveDay(raw string) (string, error) {
+	if raw == "" {
+		raw = "today"
+	}
+	now, err := dateNow()
+	if err != nil {
+		return "", err
+	}
+	span, err := parseDateArg(raw, now)
+	if err != nil {
+		return "", err
+	}
+	if span.period {
+		return "", fmt.Errorf("%q names several days; use --from/--to", raw)
+	}
+	return span.start.Format(dayLayout), nil
+}
+
+// resolveRange resolves --from/--to to YYYY-MM-DD. from takes the first day of
+// a period and to its last; an empty to means the end of from's period, or
+// today for a single day.
+func resolveRange(from, to string) (string, string, error) {
+	if from == "" {
+		return "", "", fmt.Errorf("--from is required")
+	}
+	now, err := dateNow()
+	if err != nil {
+		return "", "", err
+	}
+	start, err := parseDateArg(from, now)
+	if err != nil {
+		return "", "", fmt.Errorf("--from: %w", err)
+	}
+	end := start
+	switch {
+	case to != "":
+		if end, err = parseDateArg(to, now); err != nil {
+			return "", "", fmt.Errorf("--to: %w", err)
+		}
+	case !start.period:
+		end, _ = parseDateArg("today", now)
+	}
+	if end.end.Before(start.start) {
+		return "", "", fmt.Errorf("to must be >= from")
+	}
+	return start.start.Format(dayLayout), end.end.Format(dayLayout), nil
+}
+
+// resolveOptionalRange resolves whichever of --from and --to are set, leaving
+// a missing bound empty for the API to default; a period in from still ends
+// with it when to is unset.
+func resolveOptionalRange(from, to string) (string, string, error) {
+	if from == "" && to == "" {
+		return "", "", nil
+	}
+	now, err := dateNow()
+	if err != nil {
+		return "", "", err
+	}
+	var start, end string
+	if from != "" {
+		span, err := parseDateArg(from, now)
+		if err != nil {
+			return "", "", fmt.Errorf("--from: %w", err)
+		}
+		start = span.start.Format(dayLayout)
+		if to == "" && span.period {
+			end = span.end.Format(dayLayout)
+		}
+	}
+	if to != "" {
+		span, err := parseDateArg(to, now)
+		if err != nil {
+			return "", "", fmt.Errorf("--to: %w", err)
+		}
+		end = span.end.Format(dayLayout)
+	}
+	if start != "" && end != "" && end < start {
+		return "", "", fmt.Errorf("to must be >= from")
+	}
+	return start, end, nil
+}
+
+// resolveEventBound resolves a tempmode events bound: RFC 3339 timestamps pass
+// through, dates resolve to the first (or, with end, last) day they name.
+func resolveEventBound(raw string, end bool) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	if _, err := time.Parse(time.RFC3339, raw); err == nil {
+		return raw, nil
+	}
+	now, err := dateNow()
+	if err != nil {
+		return "", err
+	}
+	span, err := parseDateArg(raw, now)
+	if err != nil {
+		return "", err
+	}
+	if end {
+		return span.end.Format(dayLayout), nil
+	}
+	return span.start.Format(dayLayout), nil
+}
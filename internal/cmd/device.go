@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/salmonumbrella/eightsleep-cli/internal/client"
 	"github.com/salmonumbrella/eightsleep-cli/internal/output"
@@ -11,6 +13,89 @@ import (
 
 var deviceCmd = &cobra.Command{Use: "device", Short: "Device info and priming"}
 
+var deviceInfoFields = []string{
+	"id", "model", "firmware", "online", "has_water", "priming", "needs_priming",
+	"left_user_id", "left_level", "left_target", "left_heating", "left_duration",
+	"right_user_id", "right_level", "right_target", "right_heating", "right_duration",
+	"left_temp", "left_target_temp", "right_temp", "right_target_temp",
+}
+
+var deviceInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show device state",
+	Long: `Show device state. --raw prints the device payload as the API returned it,
+including fields the table does not cover.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cl, err := requireClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel, err := requestContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		table, err := temperatureTable()
+		if err != nil {
+			return err
+		}
+		unit, err := temperatureUnit()
+		if err != nil {
+			return err
+		}
+		dev, err := cl.Device().Info(ctx)
+		if err != nil {
+			return err
+		}
+		if raw, _ := cmd.Flags().GetBool("raw"); raw {
+			var payload any
+			if err := json.Unmarshal(dev.Raw, &payload); err != nil {
+				return err
+			}
+			return output.Print(outputFormat(), []string{"info"}, []map[string]any{{"info": payload}})
+		}
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, deviceInfoFields); err != nil {
+			return err
+		}
+		format := outputFormat()
+		row := deviceRow(dev)
+		temp := func(level int) any { return displayTemp(format, table.ToUnit(level, unit), unit) }
+		row["left_temp"] = temp(dev.LeftHeatingLevel)
+		row["left_target_temp"] = temp(dev.LeftTargetHeatingLevel)
+		row["right_temp"] = temp(dev.RightHeatingLevel)
+		row["right_target_temp"] = temp(dev.RightTargetHeatingLevel)
+		rows := output.FilterFields([]map[string]any{row}, fields)
+		headers := deviceInfoFields
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return output.Print(format, headers, rows)
+	},
+}
+
+func deviceRow(dev *client.Device) map[string]any {
+	return map[string]any{
+		"id":             dev.ID,
+		"model":          dev.Model,
+		"firmware":       dev.FirmwareVersion,
+		"online":         dev.Online,
+		"has_water":      dev.HasWater,
+		"priming":        dev.Priming,
+		"needs_priming":  dev.NeedsPriming,
+		"left_user_id":   dev.LeftUserID,
+		"left_level":     dev.LeftHeatingLevel,
+		"left_target":    dev.LeftTargetHeatingLevel,
+		"left_heating":   dev.LeftNowHeating,
+		"left_duration":  dev.LeftHeatingDuration,
+		"right_user_id":  dev.RightUserID,
+		"right_level":    dev.RightHeatingLevel,
+		"right_target":   dev.RightTargetHeatingLevel,
+		"right_heating":  dev.RightNowHeating,
+		"right_duration": dev.RightHeatingDuration,
+	}
+}
+
 func deviceSimple(name string, fn func(ctx context.Context, cl *client.Client) (any, error)) *cobra.Command {
 	return &cobra.Command{Use: name, RunE: func(cmd *cobra.Command, args []string) error {
 		cl, err := requireClient()
@@ -31,10 +116,9 @@ func deviceSimple(name string, fn func(ctx context.Context, cl *client.Client) (
 }
 
 func init() {
+	deviceInfoCmd.Flags().Bool("raw", false, "print the unmodified device payload")
 	deviceCmd.AddCommand(
-		deviceSimple("info", func(ctx context.Context, cl *client.Client) (any, error) {
-			return cl.Device().Info(ctx)
-		}),
+		deviceInfoCmd,
 		deviceSimple("peripherals", func(ctx context.Context, cl *client.Client) (any, error) {
 			return cl.Device().Peripherals(ctx)
 		}),
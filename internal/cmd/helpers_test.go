@@ -2,6 +2,11 @@ package cmd
 
 import (
 	"testing"
+	"time"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
+	"github.com/spf13/viper"
 )
 
 func TestFormatDays(t *testing.T) {
@@ -74,3 +79,103 @@ func TestParseZoneinfoPath(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDateArg(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	// Friday evening in New York, already Saturday in UTC.
+	now := time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC).In(loc)
+	tests := []struct {
+		input      string
+		start, end string
+		period     bool
+	}{
+		{"today", "2026-10-16", "2026-10-16", false},
+		{"last-night", "2026-10-16", "2026-10-16", false},
+		{"Yesterday", "2026-10-15", "2026-10-15", false},
+		{"-7d", "2026-10-09", "2026-10-09", false},
+		{"-2w", "2026-10-02", "2026-10-02", false},
+		{"this-week", "2026-10-12", "2026-10-16", true},
+		{"last-week", "2026-10-05", "2026-10-11", true},
+		{"this-month", "2026-10-01", "2026-10-16", true},
+		{"last-month", "2026-09-01", "2026-09-30", true},
+		{"2026-W41", "2026-10-05", "2026-10-11", true},
+		{"2020-w53", "2020-12-28", "2021-01-03", true},
+		{"2024-02", "2024-02-01", "2024-02-29", true},
+		{"2024-12-15", "2024-12-15", "2024-12-15", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			span, err := parseDateArg(tt.input, now)
+			if err != nil {
+				t.Fatalf("parseDateArg(%q): %v", tt.input, err)
+			}
+			if got := span.start.Format(dayLayout); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := span.end.Format(dayLayout); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+			if span.period != tt.period {
+				t.Errorf("period = %v, want %v", span.period, tt.period)
+			}
+		})
+	}
+	for _, bad := range []string{"", "tomorrow", "2021-W53", "2026-W00", "2024-13", "2024-02-30", "-d"} {
+		if _, err := parseDateArg(bad, now); err == nil {
+			t.Errorf("parseDateArg(%q): expected error", bad)
+		}
+	}
+}
+
+func TestResolveRange(t *testing.T) {
+	viper.Reset()
+	viper.Set("timezone", "UTC")
+	if _, _, err := resolveRange("", "2024-01-01"); err == nil {
+		t.Fatalf("expected --from required error")
+	}
+	if _, _, err := resolveRange("2024-01-02", "2024-01-01"); err == nil {
+		t.Fatalf("expected reversed range error")
+	}
+	from, to, err := resolveRange("2024-02", "")
+	if err != nil || from != "2024-02-01" || to != "2024-02-29" {
+		t.Fatalf("resolveRange(2024-02) = %s..%s, %v", from, to, err)
+	}
+	from, to, err = resolveRange("2024-W01", "2024-02")
+	if err != nil || from != "2024-01-01" || to != "2024-02-29" {
+		t.Fatalf("resolveRange(2024-W01, 2024-02) = %s..%s, %v", from, to, err)
+	}
+	if from, to, err := resolveOptionalRange("", ""); err != nil || from != "" || to != "" {
+		t.Fatalf("expected unset bounds to stay empty, got %q..%q, %v", from, to, err)
+	}
+	if from, to, err := resolveOptionalRange("", "2024-02"); err != nil || from != "" || to != "2024-02-29" {
+		t.Fatalf("resolveOptionalRange(\"\", 2024-02) = %q..%q, %v", from, to, err)
+	}
+	if from, to, err := resolveOptionalRange("2024-01-05", ""); err != nil || from != "2024-01-05" || to != "" {
+		t.Fatalf("resolveOptionalRange(2024-01-05, \"\") = %q..%q, %v", from, to, err)
+	}
+	if _, err := resolveDay("last-month"); err == nil {
+		t.Fatalf("expected resolveDay to reject a month")
+	}
+	if got, err := resolveEventBound("2024-01-01T22:00:00Z", false); err != nil || got != "2024-01-01T22:00:00Z" {
+		t.Fatalf("expected timestamp to pass through, got %q, %v", got, err)
+	}
+	if got, err := resolveEventBound("2024-02", true); err != nil || got != "2024-02-29" {
+		t.Fatalf("expected end of month, got %q, %v", got, err)
+	}
+}
+
+func TestSessionTempMissingRendersBlank(t *testing.T) {
+	temp := sessionTemp(output.FormatCSV, tempconv.Fahrenheit)
+	rows := []map[string]any{{"date": "2024-01-01", "bed_temp": temp(0, false)}}
+	out := captureStdout(t, func() {
+		if err := output.Print(output.FormatCSV, []string{"date", "bed_temp"}, rows); err != nil {
+			t.Fatalf("print: %v", err)
+		}
+	})
+	if out != "date,bed_temp\n2024-01-01," {
+		t.Fatalf("expected blank bed_temp without samples, got %q", out)
+	}
+}
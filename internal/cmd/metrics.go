@@ -21,6 +21,10 @@ var metricsTrendsCmd = &cobra.Command{Use: "trends", RunE: func(cmd *cobra.Comma
 	defer cancel()
 	from, _ := cmd.Flags().GetString("from")
 	to, _ := cmd.Flags().GetString("to")
+	from, to, err = resolveOptionalRange(from, to)
+	if err != nil {
+		return err
+	}
 	tz, err := resolveTimezone(viper.GetString("timezone"))
 	if err != nil {
 		return err
@@ -51,9 +55,9 @@ var metricsIntervalsCmd = &cobra.Command{Use: "intervals", RunE: func(cmd *cobra
 }}
 
 func init() {
-	metricsTrendsCmd.Flags().String("from", "", "from date YYYY-MM-DD")
-	metricsTrendsCmd.Flags().String("to", "", "to date YYYY-MM-DD")
-	metricsIntervalsCmd.Flags().String("id", "", "session id")
+	metricsTrendsCmd.Flags().String("from", "", "from date: "+dateHelp)
+	metricsTrendsCmd.Flags().String("to", "", "to date (default the end of a --from week or month)")
+	metricsIntervalsCmd.Flags().String("id", "", "session id (see sleep sessions)")
 
 	metricsCmd.AddCommand(metricsTrendsCmd, metricsIntervalsCmd)
 }
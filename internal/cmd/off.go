@@ -14,15 +14,28 @@ var offCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		side, err := sideFlag(cmd)
+		if err != nil {
+			return err
+		}
 		ctx, cancel, err := requestContext(cmd)
 		if err != nil {
 			return err
 		}
 		defer cancel()
-		if err := cl.TurnOff(ctx); err != nil {
+		if side != "" {
+			err = cl.TurnOffSide(ctx, side)
+		} else {
+			err = cl.TurnOff(ctx)
+		}
+		if err != nil {
 			return err
 		}
-		fmt.Println("pod turned off")
+		fmt.Printf("pod turned off%s\n", sideSuffix(side))
 		return nil
 	},
 }
+
+func init() {
+	addSideFlag(offCmd)
+}
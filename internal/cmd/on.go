@@ -14,15 +14,28 @@ var onCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		side, err := sideFlag(cmd)
+		if err != nil {
+			return err
+		}
 		ctx, cancel, err := requestContext(cmd)
 		if err != nil {
 			return err
 		}
 		defer cancel()
-		if err := cl.TurnOn(ctx); err != nil {
+		if side != "" {
+			err = cl.TurnOnSide(ctx, side)
+		} else {
+			err = cl.TurnOn(ctx)
+		}
+		if err != nil {
 			return err
 		}
-		fmt.Println("pod turned on")
+		fmt.Printf("pod turned on%s\n", sideSuffix(side))
 		return nil
 	},
 }
+
+func init() {
+	addSideFlag(onCmd)
+}
@@ -43,9 +43,11 @@ func init() {
 	rootCmd.PersistentFlags().String("timezone", "local", "IANA timezone (e.g., America/New_York) or 'local'")
 	rootCmd.PersistentFlags().String("output", "table", "output format: table|json|csv")
 	rootCmd.PersistentFlags().StringSlice("fields", []string{}, "output fields filter")
+	rootCmd.PersistentFlags().Bool("human", false, "format durations, timestamps and percentages in json/csv output too")
 	rootCmd.PersistentFlags().String("timeout", "20s", "request timeout (e.g., 30s, 1m)")
 	rootCmd.PersistentFlags().Int("retries", 2, "retry count for transient API errors")
 	rootCmd.PersistentFlags().Bool("quiet", false, "suppress config load message")
+	rootCmd.PersistentFlags().String("temp-unit", "F", "temperature display unit: F|C")
 
 	_ = viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
 	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
@@ -58,9 +60,11 @@ func init() {
 	_ = viper.BindPFlag("timezone", rootCmd.PersistentFlags().Lookup("timezone"))
 	_ = viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
 	_ = viper.BindPFlag("fields", rootCmd.PersistentFlags().Lookup("fields"))
+	_ = viper.BindPFlag("human", rootCmd.PersistentFlags().Lookup("human"))
 	_ = viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
 	_ = viper.BindPFlag("retries", rootCmd.PersistentFlags().Lookup("retries"))
 	_ = viper.BindPFlag("config-quiet", rootCmd.PersistentFlags().Lookup("quiet"))
+	_ = viper.BindPFlag("temp_unit", rootCmd.PersistentFlags().Lookup("temp-unit"))
 
 	rootCmd.AddCommand(authCmd)
 	rootCmd.AddCommand(onCmd)
@@ -83,6 +87,10 @@ func initConfig() {
 	if err != nil {
 		log.Fatalf("config: %v", err)
 	}
+	// The daemon reads, and watches for reload, the file config.Load found.
+	if cfg.Path != "" {
+		viper.SetConfigFile(cfg.Path)
+	}
 
 	// ensure env works on the main viper, too
 	viper.SetEnvPrefix("EIGHTSLEEP")
@@ -100,6 +108,8 @@ func initConfig() {
 	viper.SetDefault("verbose", cfg.Verbose)
 	viper.SetDefault("timeout", cfg.Timeout)
 	viper.SetDefault("retries", cfg.Retries)
+	viper.SetDefault("temp_unit", cfg.TempUnit)
+	viper.SetDefault("temp_calibration", cfg.TempCalibration)
 
 	// Load credentials from keyring if account is specified
 	if account := viper.GetString("account"); account != "" {
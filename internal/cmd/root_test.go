@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -59,3 +60,21 @@ func TestRequireAuthFieldsFailsWithoutCacheOrCreds(t *testing.T) {
 		t.Fatalf("expected missing credentials error")
 	}
 }
+
+func TestInitConfigExposesConfigFileToDaemon(t *testing.T) {
+	resetViper(t)
+	t.Cleanup(viper.Reset)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("timezone: UTC\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("config", path)
+	viper.Set("config-quiet", true)
+
+	initConfig()
+
+	// The daemon's Runner.ConfigFile and schedule loading both read this.
+	if got := viper.ConfigFileUsed(); got != path {
+		t.Fatalf("ConfigFileUsed = %q, want %q", got, path)
+	}
+}
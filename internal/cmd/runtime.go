@@ -11,6 +11,7 @@ import (
 
 	"github.com/salmonumbrella/eightsleep-cli/internal/client"
 	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
 )
 
 // newClient is a variable (not const) to allow tests to inject mock clients.
@@ -41,6 +42,44 @@ func outputFormat() output.Format {
 	return output.Format(viper.GetString("output"))
 }
 
+// printColumns prints rows with typed columns; formatting applies to table
+// output, and to JSON and CSV only with --human.
+func printColumns(format output.Format, headers []string, rows []map[string]any, cols output.Columns) error {
+	return output.PrintColumns(format, headers, rows, cols, viper.GetBool("human"))
+}
+
+// humanOutput reports whether printColumns would format values for format.
+func humanOutput(format output.Format) bool {
+	return output.Humanized(format, viper.GetBool("human"))
+}
+
+// temperatureTable returns the configured calibration table, or the default.
+func temperatureTable() (tempconv.Table, error) {
+	var table tempconv.Table
+	if err := viper.UnmarshalKey("temp_calibration", &table); err != nil {
+		return nil, fmt.Errorf("temp_calibration: %w", err)
+	}
+	if len(table) == 0 {
+		return tempconv.DefaultTable, nil
+	}
+	if err := table.Validate(); err != nil {
+		return nil, fmt.Errorf("temp_calibration: %w", err)
+	}
+	return table, nil
+}
+
+func temperatureUnit() (tempconv.Unit, error) {
+	return tempconv.ParseUnit(viper.GetString("temp_unit"))
+}
+
+// displayTemp keeps temperatures numeric for JSON and adds the unit suffix otherwise.
+func displayTemp(format output.Format, v float64, unit tempconv.Unit) any {
+	if format == output.FormatJSON {
+		return tempconv.Round1(v)
+	}
+	return tempconv.Format(v, unit)
+}
+
 func requestContext(cmd *cobra.Command) (context.Context, context.CancelFunc, error) {
 	timeout, err := parseTimeout(viper.GetString("timeout"))
 	if err != nil {
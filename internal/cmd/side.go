@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+)
+
+func addSideFlag(cmd *cobra.Command) {
+	cmd.Flags().String("side", "", "bed side: left|right|both (default: your side)")
+}
+
+// sideFlag returns the requested side, or "" when the flag was not set and the
+// command should act on the authenticated user's side.
+func sideFlag(cmd *cobra.Command) (client.Side, error) {
+	raw, _ := cmd.Flags().GetString("side")
+	if raw == "" {
+		return "", nil
+	}
+	return client.ParseSide(raw)
+}
+
+// sideSuffix formats a side for confirmation messages.
+func sideSuffix(side client.Side) string {
+	switch side {
+	case "":
+		return ""
+	case client.SideBoth:
+		return " (both sides)"
+	default:
+		return " (" + string(side) + " side)"
+	}
+}
@@ -1,12 +1,12 @@
 package cmd
 
 import (
-	"time"
-
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
 	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
 )
 
 var sleepCmd = &cobra.Command{
@@ -16,7 +16,7 @@ var sleepCmd = &cobra.Command{
 
 var sleepDayCmd = &cobra.Command{
 	Use:   "day",
-	Short: "Fetch sleep metrics for a date (YYYY-MM-DD)",
+	Short: "Fetch sleep metrics for a date",
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cl, err := requireClient()
@@ -28,18 +28,26 @@ var sleepDayCmd = &cobra.Command{
 			return err
 		}
 		defer cancel()
-		date := viper.GetString("date")
-		if date == "" {
-			date = time.Now().Format("2006-01-02")
+		date, err := resolveDay(viper.GetString("date"))
+		if err != nil {
+			return err
 		}
 		tz, err := resolveTimezone(viper.GetString("timezone"))
 		if err != nil {
 			return err
 		}
+		unit, err := temperatureUnit()
+		if err != nil {
+			return err
+		}
+		format := outputFormat()
 		day, err := cl.GetSleepDay(ctx, date, tz)
 		if err != nil {
 			return err
 		}
+		if stages, _ := cmd.Flags().GetBool("stages"); stages {
+			return printStageBreakdown(format, day)
+		}
 		rows := []map[string]any{
 			{
 				"date":           day.Date,
@@ -51,23 +59,110 @@ var sleepDayCmd = &cobra.Command{
 				"latency_asleep": day.LatencyAsleep,
 				"latency_out":    day.LatencyOut,
 				"hrv_score":      day.SleepQuality.HRV.Score,
+				"bed_temp":       sessionTemp(format, unit)(day.BedTempC()),
+				"room_temp":      sessionTemp(format, unit)(day.RoomTempC()),
 			},
 		}
 		fields := viper.GetStringSlice("fields")
-		if err := validateFields(fields, []string{"date", "score", "duration", "latency_asleep", "latency_out", "tnt", "resp_rate", "heart_rate", "hrv_score"}); err != nil {
+		if err := validateFields(fields, []string{"date", "score", "duration", "latency_asleep", "latency_out", "tnt", "resp_rate", "heart_rate", "hrv_score", "bed_temp", "room_temp"}); err != nil {
 			return err
 		}
 		rows = output.FilterFields(rows, fields)
-		headers := []string{"date", "score", "duration", "latency_asleep", "latency_out", "tnt", "resp_rate", "heart_rate", "hrv_score"}
+		headers := []string{"date", "score", "duration", "latency_asleep", "latency_out", "tnt", "resp_rate", "heart_rate", "hrv_score", "bed_temp", "room_temp"}
 		if len(fields) > 0 {
 			headers = fields
 		}
-		return output.Print(outputFormat(), headers, rows)
+		return printColumns(format, headers, rows, output.Columns{
+			"duration":       output.Duration,
+			"latency_asleep": output.Duration,
+			"latency_out":    output.Duration,
+		})
 	},
 }
 
+// sleepStageNames are the stages the breakdown reports; time out of bed is
+// not part of the night.
+var sleepStageNames = []string{"awake", "light", "deep", "rem"}
+
+// stageBreakdown returns each stage's minutes and share of the night in
+// percent, in sleepStageNames order. Shares are nil when no stages were recorded.
+func stageBreakdown(day *client.SleepDay) (minutes []float64, percent []any) {
+	totals := day.StageTotals()
+	var night float64
+	for _, name := range sleepStageNames {
+		night += totals[name]
+	}
+	for _, name := range sleepStageNames {
+		minutes = append(minutes, roundTo(totals[name]/60, 1))
+		percent = append(percent, round1(100*totals[name]/night, night > 0))
+	}
+	return minutes, percent
+}
+
+// addStageColumns adds <stage>_min and <stage>_pct columns to row.
+func addStageColumns(row map[string]any, day *client.SleepDay) {
+	minutes, percent := stageBreakdown(day)
+	for i, name := range sleepStageNames {
+		row[name+"_min"] = minutes[i]
+		row[name+"_pct"] = percent[i]
+	}
+}
+
+// stageColumns lists the columns addStageColumns fills.
+func stageColumns() []string {
+	cols := make([]string, 0, 2*len(sleepStageNames))
+	for _, name := range sleepStageNames {
+		cols = append(cols, name+"_min")
+	}
+	for _, name := range sleepStageNames {
+		cols = append(cols, name+"_pct")
+	}
+	return cols
+}
+
+// stagePercentColumns formats the <stage>_pct columns as percentages.
+func stagePercentColumns(cols output.Columns) output.Columns {
+	for _, name := range sleepStageNames {
+		cols[name+"_pct"] = output.Percent
+	}
+	return cols
+}
+
+func printStageBreakdown(format output.Format, day *client.SleepDay) error {
+	headers := []string{"stage", "minutes", "percent"}
+	fields := viper.GetStringSlice("fields")
+	if err := validateFields(fields, headers); err != nil {
+		return err
+	}
+	minutes, percent := stageBreakdown(day)
+	rows := make([]map[string]any, 0, len(sleepStageNames))
+	for i, name := range sleepStageNames {
+		rows = append(rows, map[string]any{"stage": name, "minutes": minutes[i], "percent": percent[i]})
+	}
+	rows = output.FilterFields(rows, fields)
+	if len(fields) > 0 {
+		headers = fields
+	}
+	return printColumns(format, headers, rows, output.Columns{"percent": output.Percent})
+}
+
+// sessionTemp converts a measured °C average into the display unit; missing samples are nil (blank in tables and CSV).
+func sessionTemp(format output.Format, unit tempconv.Unit) func(float64, bool) any {
+	return func(c float64, ok bool) any {
+		if !ok {
+			return nil
+		}
+		v := c
+		if unit == tempconv.Fahrenheit {
+			v = tempconv.CToF(c)
+		}
+		return displayTemp(format, v, unit)
+	}
+}
+
 func init() {
-	sleepCmd.PersistentFlags().String("date", "", "date YYYY-MM-DD (default today)")
+	sleepCmd.PersistentFlags().String("date", "", "date: YYYY-MM-DD, today, yesterday, last-night, -7d or -2w (default today)")
 	_ = viper.BindPFlag("date", sleepCmd.PersistentFlags().Lookup("date"))
+	sleepDayCmd.Flags().Bool("stages", false, "show minutes and share of the night per sleep stage")
 	sleepCmd.AddCommand(sleepDayCmd)
 }
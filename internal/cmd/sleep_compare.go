@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+	"github.com/salmonumbrella/eightsleep-cli/internal/stats"
+)
+
+var sleepCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare sleep metrics between two date ranges",
+	Long: `Compare sleep metrics between two date ranges.
+
+Each range is FROM..TO (inclusive), with dates in any form --from accepts, or
+a single week or month such as last-month or 2026-W41. For every metric the
+mean of range b is compared with range a, and Welch's t-test marks differences
+whose p-value is below --alpha as significant.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cl, err := requireClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel, err := requestContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		rawA, _ := cmd.Flags().GetString("a")
+		rawB, _ := cmd.Flags().GetString("b")
+		if rawA == "" || rawB == "" {
+			return fmt.Errorf("--a and --b are required")
+		}
+		now, err := dateNow()
+		if err != nil {
+			return err
+		}
+		fromA, toA, err := parseDateRange(rawA, now)
+		if err != nil {
+			return fmt.Errorf("--a: %w", err)
+		}
+		fromB, toB, err := parseDateRange(rawB, now)
+		if err != nil {
+			return fmt.Errorf("--b: %w", err)
+		}
+		alpha, _ := cmd.Flags().GetFloat64("alpha")
+		if alpha <= 0 || alpha >= 1 {
+			return fmt.Errorf("--alpha must be between 0 and 1")
+		}
+		headers := []string{"metric", "n_a", "n_b", "mean_a", "mean_b", "delta", "change_pct", "p_value", "significant"}
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, headers); err != nil {
+			return err
+		}
+		tz, err := resolveTimezone(viper.GetString("timezone"))
+		if err != nil {
+			return err
+		}
+		daysA, err := cl.Metrics().SleepDays(ctx, fromA, toA, tz)
+		if err != nil {
+			return err
+		}
+		daysB, err := cl.Metrics().SleepDays(ctx, fromB, toB, tz)
+		if err != nil {
+			return err
+		}
+		rows := sleepCompareRows(sleptDays(daysA), sleptDays(daysB), alpha)
+		rows = output.FilterFields(rows, fields)
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return printColumns(outputFormat(), headers, rows, output.Columns{"change_pct": output.Percent})
+	},
+}
+
+// parseDateRange resolves FROM..TO, or a single expression such as
+// last-month, to its first and last day.
+func parseDateRange(raw string, now time.Time) (from, to string, err error) {
+	first, last, isRange := strings.Cut(raw, "..")
+	if !isRange {
+		last = first
+	}
+	start, err := parseDateArg(first, now)
+	if err != nil {
+		return "", "", err
+	}
+	end, err := parseDateArg(last, now)
+	if err != nil {
+		return "", "", err
+	}
+	if end.end.Before(start.start) {
+		return "", "", fmt.Errorf("invalid range %q: to must be >= from", raw)
+	}
+	return start.start.Format(dayLayout), end.end.Format(dayLayout), nil
+}
+
+// sleepCompareRows emits one row per metric with the change from a to b.
+// change_pct is nil when a's mean is zero and p_value when either range has
+// too few nights to test.
+func sleepCompareRows(a, b []client.SleepDay, alpha float64) []map[string]any {
+	rows := make([]map[string]any, 0, len(sleepMetrics))
+	for _, m := range sleepMetrics {
+		va, vb := metricValues(a, m), metricValues(b, m)
+		sa, sb := stats.Summarize(va), stats.Summarize(vb)
+		delta := sb.Mean - sa.Mean
+		row := map[string]any{
+			"metric":      m.name,
+			"n_a":         sa.N,
+			"n_b":         sb.N,
+			"mean_a":      round1(sa.Mean, sa.N > 0),
+			"mean_b":      round1(sb.Mean, sb.N > 0),
+			"delta":       round1(delta, sa.N > 0 && sb.N > 0),
+			"change_pct":  round1(100*delta/sa.Mean, sa.N > 0 && sb.N > 0 && sa.Mean != 0),
+			"p_value":     nil,
+			"significant": false,
+		}
+		if t, ok := stats.Welch(va, vb); ok {
+			row["p_value"] = roundTo(t.P, 4)
+			row["significant"] = t.P < alpha
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func init() {
+	sleepCompareCmd.Flags().String("a", "", "baseline range FROM..TO or a week/month (e.g. last-month)")
+	sleepCompareCmd.Flags().String("b", "", "comparison range FROM..TO or a week/month (e.g. this-month)")
+	sleepCompareCmd.Flags().Float64("alpha", 0.05, "significance level for the t-test")
+	sleepCmd.AddCommand(sleepCompareCmd)
+}
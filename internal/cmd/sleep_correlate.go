@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+	"github.com/salmonumbrella/eightsleep-cli/internal/daemon"
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+	"github.com/salmonumbrella/eightsleep-cli/internal/stats"
+)
+
+var (
+	correlateBandFields   = []string{"band", "nights", "level", "score", "hrv_score", "latency_asleep", "score_delta"}
+	correlateMetricFields = []string{"metric", "n", "r", "p_value", "significant"}
+)
+
+var sleepCorrelateCmd = &cobra.Command{
+	Use:   "correlate",
+	Short: "Correlate heating levels with sleep outcomes",
+	Long: `Correlate heating levels with sleep outcomes.
+
+Each night's level is the mean of the levels set during it, weighted by how
+long each one held before the next change or the end of the night. Levels are
+read from the daemon journal (--source journal) or the temp-events API
+(--source events). A night runs from noon to noon and is keyed by the morning
+it ends on, like sleep day. Nights are grouped into --band wide level bands with their mean
+score, HRV and latency; --correlation reports Pearson's r for each metric
+instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cl, err := requireClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel, err := requestContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		from, to, err = resolveRange(from, to)
+		if err != nil {
+			return err
+		}
+		source, _ := cmd.Flags().GetString("source")
+		band, _ := cmd.Flags().GetInt("band")
+		if band <= 0 {
+			return fmt.Errorf("--band must be > 0")
+		}
+		alpha, _ := cmd.Flags().GetFloat64("alpha")
+		if alpha <= 0 || alpha >= 1 {
+			return fmt.Errorf("--alpha must be between 0 and 1")
+		}
+		byMetric, _ := cmd.Flags().GetBool("correlation")
+		headers := correlateBandFields
+		if byMetric {
+			headers = correlateMetricFields
+		}
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, headers); err != nil {
+			return err
+		}
+		tz, loc, err := resolveLocation()
+		if err != nil {
+			return err
+		}
+		start, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return err
+		}
+		// The first night's evening falls on the day before from.
+		eve := start.AddDate(0, 0, -1)
+		var changes []levelChange
+		switch source {
+		case "journal":
+			path, _ := cmd.Flags().GetString("journal")
+			changes, err = journalLevels(daemon.NewJournal(defaultJournalFile(path)), eve)
+		case "events":
+			var events []client.TempEvent
+			events, err = cl.TempModes().Events(ctx, eve.Format("2006-01-02"), to)
+			if err == nil {
+				changes, err = eventLevels(events)
+			}
+		default:
+			return fmt.Errorf("invalid --source %q (allowed: journal, events)", source)
+		}
+		if err != nil {
+			return err
+		}
+		days, err := cl.Metrics().SleepDays(ctx, from, to, tz)
+		if err != nil {
+			return err
+		}
+		nights := joinNightLevels(sleptDays(days), changes, loc)
+		var rows []map[string]any
+		if byMetric {
+			rows = correlateMetricRows(nights, alpha)
+		} else {
+			rows = correlateBandRows(nights, band)
+		}
+		rows = output.FilterFields(rows, fields)
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return printColumns(outputFormat(), headers, rows, output.Columns{"latency_asleep": output.Duration})
+	},
+}
+
+// levelChange is a heating level applied at a point in time.
+type levelChange struct {
+	at    time.Time
+	level int
+}
+
+// nightLevel pairs a night's sleep data with its time-weighted heating level.
+type nightLevel struct {
+	day   client.SleepDay
+	level float64
+}
+
+// journalLevels reads the temp actions the daemon applied since since.
+func journalLevels(j *daemon.Journal, since time.Time) ([]levelChange, error) {
+	entries, err := j.Read(since)
+	if err != nil {
+		return nil, err
+	}
+	table, err := temperatureTable()
+	if err != nil {
+		return nil, err
+	}
+	changes := []levelChange{}
+	for _, e := range entries {
+		if e.Action != "temp" || e.Result != daemon.ResultOK {
+			continue
+		}
+		level, err := table.Parse(e.Temperature)
+		if err != nil {
+			return nil, fmt.Errorf("journal entry %s: %w", e.Time.Format(time.RFC3339), err)
+		}
+		changes = append(changes, levelChange{at: e.Time, level: level})
+	}
+	return changes, nil
+}
+
+func eventLevels(events []client.TempEvent) ([]levelChange, error) {
+	changes := make([]levelChange, 0, len(events))
+	for _, e := range events {
+		at, err := time.Parse(time.RFC3339, e.Time)
+		if err != nil {
+			return nil, fmt.Errorf("temp event: parse ts %q: %w", e.Time, err)
+		}
+		changes = append(changes, levelChange{at: at, level: e.Level})
+	}
+	return changes, nil
+}
+
+// nightOf keys t by the morning its night ends on.
+func nightOf(t time.Time, loc *time.Location) string {
+	return t.In(loc).Add(12 * time.Hour).Format("2006-01-02")
+}
+
+// joinNightLevels keeps the nights that have both sleep data and at least one
+// level change, in date order.
+func joinNightLevels(days []client.SleepDay, changes []levelChange, loc *time.Location) []nightLevel {
+	sorted := append([]levelChange(nil), changes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].at.Before(sorted[j].at) })
+	byNight := map[string][]levelChange{}
+	for _, c := range sorted {
+		key := nightOf(c.at, loc)
+		byNight[key] = append(byNight[key], c)
+	}
+	nights := []nightLevel{}
+	for _, d := range days {
+		cs, ok := byNight[d.Date]
+		if !ok {
+			continue
+		}
+		morning, err := time.ParseInLocation(dayLayout, d.Date, loc)
+		if err != nil {
+			continue
+		}
+		nights = append(nights, nightLevel{day: d, level: weightedLevel(cs, morning.Add(12*time.Hour))})
+	}
+	sort.Slice(nights, func(i, j int) bool { return nights[i].day.Date < nights[j].day.Date })
+	return nights
+}
+
+// weightedLevel averages a night's level changes, each weighted by how long it
+// held before the next change or end.
+func weightedLevel(changes []levelChange, end time.Time) float64 {
+	var sum, total float64
+	for i, c := range changes {
+		until := end
+		if i+1 < len(changes) {
+			until = changes[i+1].at
+		}
+		if w := until.Sub(c.at).Seconds(); w > 0 {
+			sum += w * float64(c.level)
+			total += w
+		}
+	}
+	if total == 0 {
+		return float64(changes[len(changes)-1].level)
+	}
+	return sum / total
+}
+
+// correlateMetrics are the outcomes the correlate command reports.
+var correlateMetrics = []sleepMetric{scoreMetric, hrvMetric, latencyAsleepMetric}
+
+// correlateBandRows groups nights into level bands of width band, lowest first;
+// score_delta compares each band's mean score with all joined nights.
+func correlateBandRows(nights []nightLevel, band int) []map[string]any {
+	all := make([]client.SleepDay, 0, len(nights))
+	groups := map[int][]nightLevel{}
+	for _, n := range nights {
+		lo := int(math.Floor(math.Round(n.level)/float64(band))) * band
+		groups[lo] = append(groups[lo], n)
+		all = append(all, n.day)
+	}
+	overall := stats.Summarize(metricValues(all, scoreMetric)).Mean
+	los := make([]int, 0, len(groups))
+	for lo := range groups {
+		los = append(los, lo)
+	}
+	sort.Ints(los)
+	rows := make([]map[string]any, 0, len(los))
+	for _, lo := range los {
+		group := groups[lo]
+		days := make([]client.SleepDay, 0, len(group))
+		levels := make([]float64, 0, len(group))
+		for _, n := range group {
+			days = append(days, n.day)
+			levels = append(levels, n.level)
+		}
+		hi := min(lo+band-1, 100)
+		row := map[string]any{
+			"band":   fmt.Sprintf("%d..%d", lo, hi),
+			"nights": len(group),
+			"level":  round1(stats.Summarize(levels).Mean, true),
+		}
+		for _, m := range correlateMetrics {
+			row[m.name] = round1(stats.Summarize(metricValues(days, m)).Mean, true)
+		}
+		row["score_delta"] = round1(stats.Summarize(metricValues(days, scoreMetric)).Mean-overall, true)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// correlateMetricRows reports Pearson's r between night level and each
+// outcome; r and p_value are nil when there are too few nights or no variation.
+func correlateMetricRows(nights []nightLevel, alpha float64) []map[string]any {
+	levels := make([]float64, 0, len(nights))
+	days := make([]client.SleepDay, 0, len(nights))
+	for _, n := range nights {
+		levels = append(levels, n.level)
+		days = append(days, n.day)
+	}
+	rows := make([]map[string]any, 0, len(correlateMetrics))
+	for _, m := range correlateMetrics {
+		row := map[string]any{"metric": m.name, "n": len(nights), "r": nil, "p_value": nil, "significant": false}
+		if c, ok := stats.Pearson(levels, metricValues(days, m)); ok {
+			row["r"] = roundTo(c.R, 3)
+			row["p_value"] = roundTo(c.P, 4)
+			row["significant"] = c.P < alpha
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func init() {
+	sleepCorrelateCmd.Flags().String("from", "", "start date: "+dateHelp)
+	sleepCorrelateCmd.Flags().String("to", "", "end date (default today, or the end of a --from week or month)")
+	sleepCorrelateCmd.Flags().String("source", "journal", "where heating levels come from: journal|events")
+	sleepCorrelateCmd.Flags().String("journal", "", "daemon journal path (default ~/.config/eightsleep/daemon-journal.jsonl)")
+	sleepCorrelateCmd.Flags().Int("band", 10, "width of each level band")
+	sleepCorrelateCmd.Flags().Bool("correlation", false, "report Pearson's r per metric instead of level bands")
+	sleepCorrelateCmd.Flags().Float64("alpha", 0.05, "significance level for --correlation")
+	sleepCmd.AddCommand(sleepCorrelateCmd)
+}
@@ -1,12 +1,12 @@
 package cmd
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
 	"github.com/salmonumbrella/eightsleep-cli/internal/output"
 )
 
@@ -25,8 +25,9 @@ var sleepRangeCmd = &cobra.Command{
 		defer cancel()
 		from, _ := cmd.Flags().GetString("from")
 		to, _ := cmd.Flags().GetString("to")
-		if from == "" || to == "" {
-			return fmt.Errorf("--from and --to are required")
+		from, to, err = resolveRange(from, to)
+		if err != nil {
+			return err
 		}
 		layout := "2006-01-02"
 		start, err := time.Parse(layout, from)
@@ -37,20 +38,31 @@ var sleepRangeCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		if end.Before(start) {
-			return fmt.Errorf("to must be >= from")
-		}
 		tz, err := resolveTimezone(viper.GetString("timezone"))
 		if err != nil {
 			return err
 		}
+		skipMissing, _ := cmd.Flags().GetBool("skip-missing")
+		stages, _ := cmd.Flags().GetBool("stages")
+		days, err := cl.Metrics().SleepDays(ctx, from, to, tz)
+		if err != nil {
+			return err
+		}
+		byDate := make(map[string]client.SleepDay, len(days))
+		for _, day := range days {
+			byDate[day.Date] = day
+		}
 		rows := []map[string]any{}
-		for d := start; !d.After(end); d = d.Add(24 * time.Hour) {
-			day, err := cl.GetSleepDay(ctx, d.Format(layout), tz)
-			if err != nil {
-				return err
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			date := d.Format(layout)
+			day, ok := byDate[date]
+			if !ok {
+				if !skipMissing {
+					rows = append(rows, map[string]any{"date": date})
+				}
+				continue
 			}
-			rows = append(rows, map[string]any{
+			row := map[string]any{
 				"date":       day.Date,
 				"score":      day.Score,
 				"duration":   day.Duration,
@@ -58,24 +70,33 @@ var sleepRangeCmd = &cobra.Command{
 				"resp_rate":  day.Respiratory,
 				"heart_rate": day.HeartRate,
 				"hrv_score":  day.SleepQuality.HRV.Score,
-			})
+			}
+			if stages {
+				addStageColumns(row, &day)
+			}
+			rows = append(rows, row)
+		}
+		headers := []string{"date", "score", "duration", "tnt", "resp_rate", "heart_rate", "hrv_score"}
+		if stages {
+			headers = append(headers, stageColumns()...)
 		}
 		fields := viper.GetStringSlice("fields")
-		if err := validateFields(fields, []string{"date", "score", "duration", "tnt", "resp_rate", "heart_rate", "hrv_score"}); err != nil {
+		if err := validateFields(fields, headers); err != nil {
 			return err
 		}
 		rows = output.FilterFields(rows, fields)
-		headers := []string{"date", "score", "duration", "tnt", "resp_rate", "heart_rate", "hrv_score"}
 		if len(fields) > 0 {
 			headers = fields
 		}
-		return output.Print(outputFormat(), headers, rows)
+		return printColumns(outputFormat(), headers, rows, stagePercentColumns(output.Columns{"duration": output.Duration}))
 	},
 }
 
 func init() {
-	sleepRangeCmd.Flags().String("from", "", "start date YYYY-MM-DD")
-	sleepRangeCmd.Flags().String("to", "", "end date YYYY-MM-DD")
+	sleepRangeCmd.Flags().String("from", "", "start date: "+dateHelp)
+	sleepRangeCmd.Flags().String("to", "", "end date (default today, or the end of a --from week or month)")
+	sleepRangeCmd.Flags().Bool("stages", false, "add per-stage minutes and percentage columns")
+	sleepRangeCmd.Flags().Bool("skip-missing", false, "omit days without sleep data instead of emitting empty rows")
 	if sleepCmd != nil {
 		sleepCmd.AddCommand(sleepRangeCmd)
 	}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+)
+
+var sleepSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List sleep sessions with their ids for a date range",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cl, err := requireClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel, err := requestContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		from, to, err = resolveRange(from, to)
+		if err != nil {
+			return err
+		}
+		_, loc, err := resolveLocation()
+		if err != nil {
+			return err
+		}
+		layout := "2006-01-02"
+		start, err := time.ParseInLocation(layout, from, loc)
+		if err != nil {
+			return err
+		}
+		end, err := time.ParseInLocation(layout, to, loc)
+		if err != nil {
+			return err
+		}
+		if end.Before(start) {
+			return fmt.Errorf("to must be >= from")
+		}
+		sessions, truncated, err := cl.Metrics().Sessions(ctx, start, end.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		if truncated {
+			logger.Warn("stopped paging before reaching --from; older sessions may be missing", "listed", len(sessions))
+		}
+		rows := make([]map[string]any, 0, len(sessions))
+		for _, s := range sessions {
+			rows = append(rows, map[string]any{
+				"id":         s.ID,
+				"start":      s.Start.In(loc).Format(time.RFC3339),
+				"end":        s.End.In(loc).Format(time.RFC3339),
+				"score":      s.Score,
+				"asleep":     s.AsleepSeconds,
+				"incomplete": s.Incomplete,
+			})
+		}
+		headers := []string{"id", "start", "end", "score", "asleep", "incomplete"}
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, headers); err != nil {
+			return err
+		}
+		rows = output.FilterFields(rows, fields)
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return printColumns(outputFormat(), headers, rows, output.Columns{
+			"start":  output.Timestamp(loc),
+			"end":    output.Timestamp(loc),
+			"asleep": output.Duration,
+		})
+	},
+}
+
+var sleepSessionCmd = &cobra.Command{
+	Use:   "session <id>",
+	Short: "Show the stage timeline of a sleep session",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cl, err := requireClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel, err := requestContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		_, loc, err := resolveLocation()
+		if err != nil {
+			return err
+		}
+		unit, err := temperatureUnit()
+		if err != nil {
+			return err
+		}
+		format := outputFormat()
+		iv, err := cl.Metrics().Interval(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		rows, err := sessionTimeline(iv, loc, sessionTemp(format, unit))
+		if err != nil {
+			return err
+		}
+		headers := []string{"start", "end", "stage", "duration", "heart_rate", "hrv", "resp_rate", "bed_temp", "room_temp", "tnt"}
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, headers); err != nil {
+			return err
+		}
+		rows = output.FilterFields(rows, fields)
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return printColumns(format, headers, rows, output.Columns{
+			"start":    output.Timestamp(loc),
+			"end":      output.Timestamp(loc),
+			"duration": output.Duration,
+		})
+	},
+}
+
+// sessionTimeline renders one row per stage, with each timeseries averaged
+// over the stage's span and toss-and-turns summed.
+func sessionTimeline(iv *client.Interval, loc *time.Location, temp func(float64, bool) any) ([]map[string]any, error) {
+	spans, err := iv.Timeline()
+	if err != nil {
+		return nil, err
+	}
+	ts := iv.Timeseries
+	series := map[string][]sample{}
+	for name, points := range map[string][]client.TimeseriesPoint{
+		"heart_rate": ts.HeartRate,
+		"hrv":        ts.HRV,
+		"resp_rate":  ts.RespiratoryRate,
+		"bed_temp":   ts.TempBedC,
+		"room_temp":  ts.TempRoomC,
+		"tnt":        ts.TossAndTurns,
+	} {
+		parsed, err := parseSamples(points)
+		if err != nil {
+			return nil, fmt.Errorf("session %s %s: %w", iv.ID, name, err)
+		}
+		series[name] = parsed
+	}
+	rows := make([]map[string]any, 0, len(spans))
+	for _, sp := range spans {
+		avg := func(name string) (float64, bool) { return spanAverage(series[name], sp) }
+		tnt, _ := spanSum(series["tnt"], sp)
+		rows = append(rows, map[string]any{
+			"start":      sp.Start.In(loc).Format(time.RFC3339),
+			"end":        sp.End.In(loc).Format(time.RFC3339),
+			"stage":      sp.Stage,
+			"duration":   sp.End.Sub(sp.Start).Seconds(),
+			"heart_rate": round1(avg("heart_rate")),
+			"hrv":        round1(avg("hrv")),
+			"resp_rate":  round1(avg("resp_rate")),
+			"bed_temp":   temp(avg("bed_temp")),
+			"room_temp":  temp(avg("room_temp")),
+			"tnt":        tnt,
+		})
+	}
+	return rows, nil
+}
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+func parseSamples(points []client.TimeseriesPoint) ([]sample, error) {
+	out := make([]sample, 0, len(points))
+	for _, p := range points {
+		at, err := time.Parse(time.RFC3339, p.Time)
+		if err != nil {
+			return nil, fmt.Errorf("parse time %q: %w", p.Time, err)
+		}
+		out = append(out, sample{at: at, value: p.Value})
+	}
+	return out, nil
+}
+
+// spanSum totals the samples that fall within [sp.Start, sp.End).
+func spanSum(samples []sample, sp client.StageSpan) (sum float64, n int) {
+	for _, s := range samples {
+		if !s.at.Before(sp.Start) && s.at.Before(sp.End) {
+			sum += s.value
+			n++
+		}
+	}
+	return sum, n
+}
+
+func spanAverage(samples []sample, sp client.StageSpan) (float64, bool) {
+	sum, n := spanSum(samples, sp)
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// round1 rounds a present value to one decimal place; missing values render as nil.
+func round1(v float64, ok bool) any {
+	if !ok {
+		return nil
+	}
+	return roundTo(v, 1)
+}
+
+func roundTo(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+func init() {
+	sleepSessionsCmd.Flags().String("from", "", "start date: "+dateHelp)
+	sleepSessionsCmd.Flags().String("to", "", "end date (default today, or the end of a --from week or month)")
+	sleepCmd.AddCommand(sleepSessionsCmd, sleepSessionCmd)
+}
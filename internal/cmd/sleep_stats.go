@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+	"github.com/salmonumbrella/eightsleep-cli/internal/output"
+	"github.com/salmonumbrella/eightsleep-cli/internal/stats"
+)
+
+// sleepMetric is a per-night value the analysis commands aggregate; names
+// match the sleep day columns. format, when set, renders the metric's values
+// for people, as the sleep day column of the same name does.
+type sleepMetric struct {
+	name   string
+	value  func(client.SleepDay) float64
+	format output.Formatter
+}
+
+var (
+	scoreMetric         = sleepMetric{name: "score", value: func(d client.SleepDay) float64 { return d.Score }}
+	hrvMetric           = sleepMetric{name: "hrv_score", value: func(d client.SleepDay) float64 { return d.SleepQuality.HRV.Score }}
+	latencyAsleepMetric = sleepMetric{name: "latency_asleep", value: func(d client.SleepDay) float64 { return d.LatencyAsleep }, format: output.Duration}
+)
+
+var sleepMetrics = []sleepMetric{
+	scoreMetric,
+	{name: "duration", value: func(d client.SleepDay) float64 { return d.Duration }, format: output.Duration},
+	{name: "heart_rate", value: func(d client.SleepDay) float64 { return d.HeartRate }},
+	hrvMetric,
+	{name: "resp_rate", value: func(d client.SleepDay) float64 { return d.Respiratory }},
+	latencyAsleepMetric,
+}
+
+var sleepStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize sleep metrics over a date range",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cl, err := requireClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel, err := requestContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		from, to, err = resolveRange(from, to)
+		if err != nil {
+			return err
+		}
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		keyFn, err := sleepGroupKey(groupBy)
+		if err != nil {
+			return err
+		}
+		headers := []string{"group", "metric", "n", "mean", "median", "min", "max", "stddev"}
+		fields := viper.GetStringSlice("fields")
+		if err := validateFields(fields, headers); err != nil {
+			return err
+		}
+		tz, err := resolveTimezone(viper.GetString("timezone"))
+		if err != nil {
+			return err
+		}
+		days, err := cl.Metrics().SleepDays(ctx, from, to, tz)
+		if err != nil {
+			return err
+		}
+		rows, err := sleepStatsRows(days, keyFn)
+		if err != nil {
+			return err
+		}
+		format := outputFormat()
+		if humanOutput(format) {
+			rows = formatMetricRows(rows, "mean", "median", "min", "max", "stddev")
+		}
+		rows = output.FilterFields(rows, fields)
+		if len(fields) > 0 {
+			headers = fields
+		}
+		return output.Print(format, headers, rows)
+	},
+}
+
+// sleepGroup labels a night and orders its group among the others.
+type sleepGroup struct {
+	label string
+	order string
+}
+
+// sleepGroupKey returns the grouping for --group-by; an empty value puts every
+// night in a single "all" group.
+func sleepGroupKey(groupBy string) (func(time.Time) sleepGroup, error) {
+	switch groupBy {
+	case "":
+		return func(time.Time) sleepGroup { return sleepGroup{label: "all"} }, nil
+	case "week":
+		return func(t time.Time) sleepGroup {
+			y, w := t.ISOWeek()
+			label := fmt.Sprintf("%d-W%02d", y, w)
+			return sleepGroup{label: label, order: label}
+		}, nil
+	case "month":
+		return func(t time.Time) sleepGroup {
+			label := t.Format("2006-01")
+			return sleepGroup{label: label, order: label}
+		}, nil
+	case "weekday":
+		return func(t time.Time) sleepGroup {
+			// Monday first.
+			return sleepGroup{label: t.Weekday().String()[:3], order: fmt.Sprint((int(t.Weekday()) + 6) % 7)}
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid --group-by %q (allowed: week, month, weekday)", groupBy)
+	}
+}
+
+// sleepStatsRows emits one row per group and metric.
+func sleepStatsRows(days []client.SleepDay, keyFn func(time.Time) sleepGroup) ([]map[string]any, error) {
+	groups := map[sleepGroup][]client.SleepDay{}
+	for _, d := range sleptDays(days) {
+		date, err := time.Parse("2006-01-02", d.Date)
+		if err != nil {
+			return nil, fmt.Errorf("parse sleep day %q: %w", d.Date, err)
+		}
+		key := keyFn(date)
+		groups[key] = append(groups[key], d)
+	}
+	keys := make([]sleepGroup, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].order < keys[j].order })
+	rows := []map[string]any{}
+	for _, k := range keys {
+		for _, m := range sleepMetrics {
+			s := stats.Summarize(metricValues(groups[k], m))
+			rows = append(rows, map[string]any{
+				"group":  k.label,
+				"metric": m.name,
+				"n":      s.N,
+				"mean":   round1(s.Mean, true),
+				"median": round1(s.Median, true),
+				"min":    round1(s.Min, true),
+				"max":    round1(s.Max, true),
+				"stddev": round1(s.StdDev, s.N > 1),
+			})
+		}
+	}
+	return rows, nil
+}
+
+// sleptDays drops nights without recorded sleep so they don't drag averages
+// towards zero.
+func sleptDays(days []client.SleepDay) []client.SleepDay {
+	out := make([]client.SleepDay, 0, len(days))
+	for _, d := range days {
+		if d.Duration > 0 {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// formatMetricRows returns copies of rows with cols formatted by the kind of
+// each row's metric, so durations read as "7h 32m" next to plain scores.
+func formatMetricRows(rows []map[string]any, cols ...string) []map[string]any {
+	formats := map[string]output.Formatter{}
+	for _, m := range sleepMetrics {
+		if m.format != nil {
+			formats[m.name] = m.format
+		}
+	}
+	out := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		name, _ := row["metric"].(string)
+		f, ok := formats[name]
+		if !ok {
+			out = append(out, row)
+			continue
+		}
+		byCol := make(output.Columns, len(cols))
+		for _, c := range cols {
+			byCol[c] = f
+		}
+		out = append(out, output.Humanize([]map[string]any{row}, byCol)[0])
+	}
+	return out
+}
+
+func metricValues(days []client.SleepDay, m sleepMetric) []float64 {
+	values := make([]float64, 0, len(days))
+	for _, d := range days {
+		values = append(values, m.value(d))
+	}
+	return values
+}
+
+func init() {
+	sleepStatsCmd.Flags().String("from", "", "start date: "+dateHelp)
+	sleepStatsCmd.Flags().String("to", "", "end date (default today, or the end of a --from week or month)")
+	sleepStatsCmd.Flags().String("group-by", "", "group nights by week, month or weekday")
+	sleepCmd.AddCommand(sleepStatsCmd)
+}
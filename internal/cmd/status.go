@@ -15,25 +15,64 @@ var statusCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		ctx, cancel, err := requestContext(cmd)
+		side, err := sideFlag(cmd)
 		if err != nil {
 			return err
 		}
-		defer cancel()
-		st, err := cl.GetStatus(ctx)
+		table, err := temperatureTable()
 		if err != nil {
 			return err
 		}
-		row := map[string]any{"mode": st.CurrentState.Type, "level": st.CurrentLevel}
+		unit, err := temperatureUnit()
+		if err != nil {
+			return err
+		}
+		format := outputFormat()
+		ctx, cancel, err := requestContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		var rows []map[string]any
+		allowed := []string{"mode", "level", "temp"}
+		if side != "" {
+			statuses, err := cl.GetStatusSide(ctx, side)
+			if err != nil {
+				return err
+			}
+			for _, st := range statuses {
+				rows = append(rows, map[string]any{
+					"side":  string(st.Side),
+					"mode":  st.CurrentState.Type,
+					"level": st.CurrentLevel,
+					"temp":  displayTemp(format, table.ToUnit(st.CurrentLevel, unit), unit),
+				})
+			}
+			allowed = []string{"side", "mode", "level", "temp"}
+		} else {
+			st, err := cl.GetStatus(ctx)
+			if err != nil {
+				return err
+			}
+			rows = []map[string]any{{
+				"mode":  st.CurrentState.Type,
+				"level": st.CurrentLevel,
+				"temp":  displayTemp(format, table.ToUnit(st.CurrentLevel, unit), unit),
+			}}
+		}
 		fields := viper.GetStringSlice("fields")
-		if err := validateFields(fields, []string{"mode", "level"}); err != nil {
+		if err := validateFields(fields, allowed); err != nil {
 			return err
 		}
-		rows := output.FilterFields([]map[string]any{row}, fields)
+		rows = output.FilterFields(rows, fields)
 		headers := fields
 		if len(headers) == 0 {
-			headers = []string{"mode", "level"}
+			headers = allowed
 		}
-		return output.Print(outputFormat(), headers, rows)
+		return output.Print(format, headers, rows)
 	},
 }
+
+func init() {
+	addSideFlag(statusCmd)
+}
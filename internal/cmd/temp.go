@@ -2,10 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/salmonumbrella/eightsleep-cli/internal/daemon"
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
 )
 
 var tempCmd = &cobra.Command{
@@ -17,19 +18,47 @@ var tempCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		lvl, err := daemon.ParseTemp(args[0])
+		table, err := temperatureTable()
 		if err != nil {
 			return err
 		}
+		lvl, err := table.Parse(args[0])
+		if err != nil {
+			return err
+		}
+		side, err := sideFlag(cmd)
+		if err != nil {
+			return err
+		}
+		viaRaw, _ := cmd.Flags().GetString("via")
+		via, err := client.ParseTempPath(viaRaw)
+		if err != nil {
+			return err
+		}
+		duration, _ := cmd.Flags().GetDuration("duration")
+		if duration < 0 {
+			return fmt.Errorf("--duration must be >= 0")
+		}
 		ctx, cancel, err := requestContext(cmd)
 		if err != nil {
 			return err
 		}
 		defer cancel()
-		if err := cl.SetTemperature(ctx, lvl); err != nil {
+		secs := int(duration / time.Second)
+		if err := cl.SetTemperatureVia(ctx, via, side, lvl, secs); err != nil {
 			return err
 		}
-		fmt.Printf("temperature set (level %d)\n", lvl)
+		msg := fmt.Sprintf("temperature set (level %d", lvl)
+		if secs > 0 {
+			msg += ", for " + duration.String()
+		}
+		fmt.Printf("%s)%s\n", msg, sideSuffix(side))
 		return nil
 	},
 }
+
+func init() {
+	addSideFlag(tempCmd)
+	tempCmd.Flags().Duration("duration", 0, "how long to hold the level (e.g., 2h, 90m; default indefinite); always set through the device endpoint")
+	tempCmd.Flags().String("via", "auto", "temperature endpoint: device|user|auto (auto detects pod generation)")
+}
@@ -153,6 +153,12 @@ var tempEventsCmd = &cobra.Command{Use: "events", Short: "Show temperature event
 	defer cancel()
 	from, _ := cmd.Flags().GetString("from")
 	to, _ := cmd.Flags().GetString("to")
+	if from, err = resolveEventBound(from, false); err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	if to, err = resolveEventBound(to, true); err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
 	var out any
 	if err := cl.TempModes().TempEvents(ctx, from, to, &out); err != nil {
 		return err
@@ -211,8 +217,8 @@ func mapKeys(m map[string]any) []string {
 }
 
 func init() {
-	tempEventsCmd.Flags().String("from", "", "from date (ISO 8601)")
-	tempEventsCmd.Flags().String("to", "", "to date (ISO 8601)")
+	tempEventsCmd.Flags().String("from", "", "from RFC 3339 timestamp or date: "+dateHelp)
+	tempEventsCmd.Flags().String("to", "", "to RFC 3339 timestamp or date")
 
 	napCmd.AddCommand(napOnCmd, napOffCmd, napExtendCmd, napStatusCmd)
 	hotflashCmd.AddCommand(hotflashOnCmd, hotflashOffCmd, hotflashStatusCmd)
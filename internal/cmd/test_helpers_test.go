@@ -79,6 +79,20 @@ func newTestServer(t *testing.T) *httptest.Server {
 		}
 	})
 
+	mux.HandleFunc("/users/uid-456/temperature", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, map[string]any{
+				"currentLevel": -20,
+				"currentState": map[string]any{"type": "smart"},
+			})
+		case http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
 	mux.HandleFunc("/users/uid-123/trends", func(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, map[string]any{
 			"days": []map[string]any{
@@ -91,23 +105,88 @@ func newTestServer(t *testing.T) *httptest.Server {
 					"latencyAsleepSeconds": 300,
 					"latencyOutSeconds":    120,
 					"sleepDurationSeconds": 28800,
+					"stages": []map[string]any{
+						{"stage": "awake", "duration": 900},
+						{"stage": "light", "duration": 7200},
+						{"stage": "deep", "duration": 3600},
+						{"stage": "light", "duration": 5400},
+						{"stage": "rem", "duration": 5400},
+						{"stage": "awake", "duration": 900},
+						{"stage": "rem", "duration": 3600},
+						{"stage": "out", "duration": 300},
+					},
 					"sleepQualityScore": map[string]any{
 						"hrv":             map[string]any{"score": 85},
 						"respiratoryRate": map[string]any{"score": 80},
 					},
+					"sessions": []map[string]any{
+						{
+							"id": "session-1",
+							"timeseries": map[string]any{
+								"tempBedC":  [][]any{{"2024-01-01T23:00:00Z", 30.0}, {"2024-01-02T03:00:00Z", 32.0}},
+								"tempRoomC": [][]any{{"2024-01-01T23:00:00Z", 20.0}},
+							},
+						},
+					},
 				},
 			},
 		})
 	})
 
+	session := map[string]any{
+		"id":    "session-1",
+		"ts":    "2024-01-01T23:00:00Z",
+		"score": 90,
+		"stages": []map[string]any{
+			{"stage": "awake", "duration": 600},
+			{"stage": "light", "duration": 3600},
+			{"stage": "deep", "duration": 1800},
+		},
+		"timeseries": map[string]any{
+			"heartRate": [][]any{{"2024-01-01T23:05:00Z", 64.0}, {"2024-01-01T23:30:00Z", 58.0}, {"2024-01-01T23:40:00Z", 57.0}},
+			"tempBedC":  [][]any{{"2024-01-02T00:20:00Z", 30.0}},
+			"tnt":       [][]any{{"2024-01-01T23:20:00Z", 1.0}, {"2024-01-01T23:50:00Z", 1.0}},
+		},
+	}
+	mux.HandleFunc("/users/uid-123/intervals", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"intervals": []map[string]any{session}})
+	})
 	mux.HandleFunc("/users/uid-123/intervals/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"intervals": []map[string]any{session}})
+	})
+
+	mux.HandleFunc("/users/uid-123/temp-events", func(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, map[string]any{
-			"intervals": []map[string]any{{"id": "session-1"}},
+			"events": []map[string]any{
+				{"ts": "2023-12-31T22:00:00Z", "level": -10},
+				{"ts": "2024-01-01T03:00:00Z", "level": -30},
+			},
 		})
 	})
 
 	mux.HandleFunc("/devices/dev-1", func(w http.ResponseWriter, r *http.Request) {
-		writeJSON(w, map[string]any{"id": "dev-1"})
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, map[string]any{
+				"result": map[string]any{
+					"deviceId":               "dev-1",
+					"modelString":            "Pod 4",
+					"firmwareVersion":        "2.3.4",
+					"online":                 true,
+					"hasWater":               true,
+					"leftUserId":             "uid-123",
+					"rightUserId":            "uid-456",
+					"leftHeatingLevel":       -20,
+					"leftTargetHeatingLevel": -30,
+					"leftNowHeating":         true,
+					"rightHeatingLevel":      10,
+				},
+			})
+		case http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
 	})
 	mux.HandleFunc("/devices/dev-1/peripherals", func(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, map[string]any{"peripherals": []string{"fan"}})
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/spf13/viper"
 )
 
 func resolveTimezone(name string) (string, error) {
@@ -19,6 +21,19 @@ func resolveTimezone(name string) (string, error) {
 	return name, nil
 }
 
+// resolveLocation resolves the configured --timezone to its IANA name and location.
+func resolveLocation() (string, *time.Location, error) {
+	tz, err := resolveTimezone(viper.GetString("timezone"))
+	if err != nil {
+		return "", nil, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return "", nil, err
+	}
+	return tz, loc, nil
+}
+
 // localTimezoneName attempts to resolve the local timezone to an IANA name.
 // Note: This function uses Unix-specific paths (/etc/localtime, /etc/timezone)
 // and will not work on Windows. On Windows, time.Now().Location().String() may
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/spf13/viper"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
 )
 
 // Config holds merged configuration.
@@ -22,6 +24,11 @@ type Config struct {
 	Verbose      bool     `mapstructure:"verbose"`
 	Timeout      string   `mapstructure:"timeout"`
 	Retries      int      `mapstructure:"retries"`
+	TempUnit     string   `mapstructure:"temp_unit"`
+	// TempCalibration overrides tempconv.DefaultTable when set.
+	TempCalibration tempconv.Table `mapstructure:"temp_calibration"`
+	// Path is the config file that was read, if any.
+	Path string `mapstructure:"-"`
 }
 
 // Load initializes viper and unmarshals Config.
@@ -49,8 +56,11 @@ func Load(configPath string, quiet bool) (Config, error) {
 	v.SetDefault("output", "table")
 	v.SetDefault("timeout", "20s")
 	v.SetDefault("retries", 2)
+	v.SetDefault("temp_unit", "F")
 
+	path := ""
 	if err := v.ReadInConfig(); err == nil {
+		path = v.ConfigFileUsed()
 		if !quiet {
 			fmt.Fprintf(os.Stderr, "Using config file: %s\n", v.ConfigFileUsed())
 		}
@@ -60,6 +70,7 @@ func Load(configPath string, quiet bool) (Config, error) {
 	if err := v.Unmarshal(&cfg); err != nil {
 		return Config{}, fmt.Errorf("decode config: %w", err)
 	}
+	cfg.Path = path
 
 	return cfg, nil
 }
@@ -0,0 +1,212 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
+)
+
+// Actions lists every action a schedule item can take.
+var Actions = []string{
+	"on", "off", "temp",
+	"nap-on", "nap-off", "hotflash-on", "hotflash-off",
+	"alarm-one-off",
+}
+
+// ScheduleAlarm configures an alarm-one-off action. Vibration and thermal
+// wake-up are enabled unless turned off, with the same defaults as
+// `eightsleep alarm one-off`.
+type ScheduleAlarm struct {
+	Time             string `mapstructure:"time" yaml:"time"`
+	VibrationLevel   *int   `mapstructure:"vibration_level" yaml:"vibration_level,omitempty"`
+	VibrationPattern string `mapstructure:"vibration_pattern" yaml:"vibration_pattern,omitempty"`
+	NoVibration      bool   `mapstructure:"no_vibration" yaml:"no_vibration,omitempty"`
+	ThermalLevel     int    `mapstructure:"thermal_level" yaml:"thermal_level,omitempty"`
+	NoThermal        bool   `mapstructure:"no_thermal" yaml:"no_thermal,omitempty"`
+}
+
+func (a ScheduleAlarm) oneOff() client.OneOffAlarm {
+	level := 50
+	if a.VibrationLevel != nil {
+		level = *a.VibrationLevel
+	}
+	pattern := a.VibrationPattern
+	if pattern == "" {
+		pattern = "RISE"
+	}
+	return client.OneOffAlarm{
+		Time:             a.Time,
+		Enabled:          true,
+		VibrationEnabled: !a.NoVibration,
+		VibrationLevel:   level,
+		VibrationPattern: pattern,
+		ThermalEnabled:   !a.NoThermal,
+		ThermalLevel:     a.ThermalLevel,
+	}
+}
+
+func (a ScheduleAlarm) validate() error {
+	if _, err := time.Parse("15:04", a.Time); err != nil {
+		if _, err := time.Parse("15:04:05", a.Time); err != nil {
+			return fmt.Errorf("alarm time must be HH:MM, got %q", a.Time)
+		}
+	}
+	alarm := a.oneOff()
+	if alarm.VibrationLevel < 0 || alarm.VibrationLevel > 100 {
+		return fmt.Errorf("alarm vibration_level must be between 0 and 100")
+	}
+	if alarm.ThermalLevel < -100 || alarm.ThermalLevel > 100 {
+		return fmt.Errorf("alarm thermal_level must be between -100 and 100")
+	}
+	if strings.TrimSpace(alarm.VibrationPattern) == "" {
+		return fmt.Errorf("alarm vibration_pattern cannot be blank")
+	}
+	return nil
+}
+
+// duration parses the item's temp duration; zero means indefinite.
+func (s ScheduleItem) duration() (time.Duration, error) {
+	if s.Duration == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s.Duration)
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("invalid duration %q (e.g. 90m, 2h)", s.Duration)
+	}
+	return d, nil
+}
+
+// validateAction checks the action name and that it carries exactly the
+// parameters it uses.
+func (s ScheduleItem) validateAction() error {
+	switch s.Action {
+	case "temp":
+		if s.Temperature == "" {
+			return fmt.Errorf("temp action requires temperature")
+		}
+		// Syntax only; the configured calibration decides the level.
+		if _, err := tempconv.DefaultTable.Parse(s.Temperature); err != nil {
+			return err
+		}
+		if _, err := s.duration(); err != nil {
+			return err
+		}
+	case "alarm-one-off":
+		if s.Alarm == nil {
+			return fmt.Errorf("alarm-one-off action requires alarm.time")
+		}
+		if err := s.Alarm.validate(); err != nil {
+			return err
+		}
+	case "on", "off", "nap-on", "nap-off", "hotflash-on", "hotflash-off":
+	default:
+		return fmt.Errorf("unknown action %s (allowed: %s)", s.Action, strings.Join(Actions, ", "))
+	}
+	if s.Temperature != "" && s.Action != "temp" {
+		return fmt.Errorf("temperature only applies to the temp action")
+	}
+	if s.Duration != "" && s.Action != "temp" {
+		return fmt.Errorf("duration only applies to the temp action")
+	}
+	if s.Alarm != nil && s.Action != "alarm-one-off" {
+		return fmt.Errorf("alarm only applies to the alarm-one-off action")
+	}
+	return nil
+}
+
+// Controller is the set of client capabilities the runner drives.
+type Controller interface {
+	TurnOn(ctx context.Context) error
+	TurnOff(ctx context.Context) error
+	// SetLevel sets the heating level for duration seconds, or indefinitely
+	// when duration is zero.
+	SetLevel(ctx context.Context, level int, duration int) error
+	GetStatus(ctx context.Context) (*client.TempStatus, error)
+	NapActivate(ctx context.Context) error
+	NapDeactivate(ctx context.Context) error
+	HotFlashActivate(ctx context.Context) error
+	HotFlashDeactivate(ctx context.Context) error
+	SetOneOffAlarm(ctx context.Context, alarm client.OneOffAlarm) error
+	// RecentIntervals feeds When conditions.
+	RecentIntervals(ctx context.Context) ([]client.Interval, error)
+}
+
+// ClientController adapts a client, whose nap, hot-flash and device actions
+// are grouped behind accessors, to Controller.
+type ClientController struct {
+	*client.Client
+}
+
+var _ Controller = ClientController{}
+
+func (c ClientController) NapActivate(ctx context.Context) error {
+	return c.TempModes().NapActivate(ctx)
+}
+
+func (c ClientController) NapDeactivate(ctx context.Context) error {
+	return c.TempModes().NapDeactivate(ctx)
+}
+
+func (c ClientController) HotFlashActivate(ctx context.Context) error {
+	return c.TempModes().HotFlashActivate(ctx)
+}
+
+func (c ClientController) HotFlashDeactivate(ctx context.Context) error {
+	return c.TempModes().HotFlashDeactivate(ctx)
+}
+
+// SetLevel goes through the endpoint suited to the pod, as `eightsleep temp`
+// does by default.
+func (c ClientController) SetLevel(ctx context.Context, level int, duration int) error {
+	return c.SetTemperatureVia(ctx, client.TempPathAuto, "", level, duration)
+}
+
+func (c ClientController) RecentIntervals(ctx context.Context) ([]client.Interval, error) {
+	return c.Metrics().RecentIntervals(ctx)
+}
+
+// errInvalidAction marks errors that retrying cannot fix.
+var errInvalidAction = errors.New("invalid action")
+
+func (r *Runner) execute(ctx context.Context, item ScheduleItem) error {
+	switch item.Action {
+	case "on":
+		return r.Client.TurnOn(ctx)
+	case "off":
+		return r.Client.TurnOff(ctx)
+	case "temp":
+		level, err := r.temps().Parse(item.Temperature)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidAction, err)
+		}
+		d, err := item.duration()
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidAction, err)
+		}
+		if err := r.Client.SetLevel(ctx, level, int(d/time.Second)); err != nil {
+			return err
+		}
+		r.Metrics.setBedLevel(level)
+		return nil
+	case "nap-on":
+		return r.Client.NapActivate(ctx)
+	case "nap-off":
+		return r.Client.NapDeactivate(ctx)
+	case "hotflash-on":
+		return r.Client.HotFlashActivate(ctx)
+	case "hotflash-off":
+		return r.Client.HotFlashDeactivate(ctx)
+	case "alarm-one-off":
+		if item.Alarm == nil {
+			return fmt.Errorf("%w: alarm-one-off action requires alarm.time", errInvalidAction)
+		}
+		return r.Client.SetOneOffAlarm(ctx, item.Alarm.oneOff())
+	default:
+		return fmt.Errorf("%w: unknown action %s", errInvalidAction, item.Action)
+	}
+}
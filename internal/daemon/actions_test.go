@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateAction(t *testing.T) {
+	vib := 120
+	tests := []struct {
+		name    string
+		item    ScheduleItem
+		wantErr string
+	}{
+		{"on", ScheduleItem{Action: "on"}, ""},
+		{"nap", ScheduleItem{Action: "nap-on"}, ""},
+		{"prime", ScheduleItem{Action: "prime"}, "unknown action"},
+		{"timed temp", ScheduleItem{Action: "temp", Temperature: "68F", Duration: "2h"}, ""},
+		{"alarm", ScheduleItem{Action: "alarm-one-off", Alarm: &ScheduleAlarm{Time: "06:30"}}, ""},
+		{"unknown", ScheduleItem{Action: "dance"}, "unknown action"},
+		{"temp missing", ScheduleItem{Action: "temp"}, "requires temperature"},
+		{"temp invalid", ScheduleItem{Action: "temp", Temperature: "warm"}, "temperature"},
+		{"bad duration", ScheduleItem{Action: "temp", Temperature: "68F", Duration: "soon"}, "invalid duration"},
+		{"duration on wrong action", ScheduleItem{Action: "on", Duration: "1h"}, "duration only applies"},
+		{"temperature on wrong action", ScheduleItem{Action: "nap-on", Temperature: "68F"}, "temperature only applies"},
+		{"alarm missing", ScheduleItem{Action: "alarm-one-off"}, "requires alarm.time"},
+		{"alarm bad time", ScheduleItem{Action: "alarm-one-off", Alarm: &ScheduleAlarm{Time: "6.30"}}, "HH:MM"},
+		{"alarm bad vibration", ScheduleItem{Action: "alarm-one-off", Alarm: &ScheduleAlarm{Time: "06:30", VibrationLevel: &vib}}, "vibration_level"},
+		{"alarm on wrong action", ScheduleItem{Action: "off", Alarm: &ScheduleAlarm{Time: "06:30"}}, "alarm only applies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.item.validateAction()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRunnerExecutesAllActions(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 59, 30, 0, loc))
+	ctrl := &fakeController{}
+	r := &Runner{
+		Items: []ScheduleItem{
+			{Time: "22:00", Action: "nap-on"},
+			{Time: "22:00", Action: "nap-off"},
+			{Time: "22:00", Action: "hotflash-on"},
+			{Time: "22:00", Action: "hotflash-off"},
+			{Time: "22:00", Action: "temp", Temperature: "-30", Duration: "90m"},
+			{Time: "22:00", Action: "alarm-one-off", Alarm: &ScheduleAlarm{Time: "06:30", NoThermal: true}},
+		},
+		Client:   ctrl,
+		Timezone: loc,
+		Clock:    clock,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute)
+	clock.settle()
+	stop()
+	want := []string{
+		"nap-on", "nap-off", "hotflash-on", "hotflash-off",
+		"temp -30 for 5400s",
+		"alarm 06:30 vib=true/50 thermal=false/0",
+	}
+	if got := ctrl.Calls(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSyncIgnoresExpiredTimedTemp(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	r := &Runner{
+		Items:    []ScheduleItem{{Time: "22:00", Action: "temp", Temperature: "-30", Duration: "1h"}},
+		Timezone: loc,
+	}
+	triggers, err := compileSchedule(r.Items, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := r.desired(triggers, time.Date(2024, 1, 1, 22, 30, 0, 0, loc))
+	if err != nil || st.Level == nil {
+		t.Fatalf("expected timed temp in effect at 22:30: %+v, %v", st, err)
+	}
+	st, err = r.desired(triggers, time.Date(2024, 1, 1, 23, 30, 0, 0, loc))
+	if err != nil || st.Level != nil {
+		t.Fatalf("expected expired timed temp to be ignored: %+v, %v", st, err)
+	}
+}
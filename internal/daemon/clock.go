@@ -0,0 +1,19 @@
+package daemon
+
+import "time"
+
+// Clock abstracts time so the runner can be driven deterministically in tests.
+type Clock interface {
+	Now() time.Time
+	// NewTicker returns a channel that delivers the current time every d, and a stop func.
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
@@ -0,0 +1,332 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+)
+
+// DefaultPollInterval is how often the runner reads sleep data for items with
+// a When condition.
+const DefaultPollInterval = 2 * time.Minute
+
+// Sleep stages as reported by the intervals endpoint.
+var sleepStages = map[string]bool{"awake": true, "light": true, "deep": true, "rem": true, "out": true}
+
+// SleepState is the user's current situation, derived from the latest sleep
+// session.
+type SleepState struct {
+	// Session is the ID of the latest session; empty when there is none.
+	Session  string
+	Presence bool
+	// Stage is the latest stage of an in-progress session.
+	Stage       string
+	InBedSince  time.Time
+	AsleepSince time.Time
+}
+
+// sleepStateFrom reads the newest interval: the user is present while it is
+// incomplete and its last stage is not "out", and asleep since the first
+// light, deep or REM stage.
+func sleepStateFrom(intervals []client.Interval) (SleepState, error) {
+	if len(intervals) == 0 {
+		return SleepState{}, nil
+	}
+	iv := intervals[0]
+	st := SleepState{Session: iv.ID}
+	if !iv.Incomplete {
+		return st, nil
+	}
+	start, err := iv.StartTime()
+	if err != nil {
+		return SleepState{}, err
+	}
+	st.InBedSince = start
+	at := start
+	for _, s := range iv.Stages {
+		if st.AsleepSince.IsZero() && isSleepStage(s.Stage) {
+			st.AsleepSince = at
+		}
+		at = at.Add(time.Duration(s.Duration * float64(time.Second)))
+		st.Stage = s.Stage
+	}
+	st.Presence = st.Stage != "out"
+	if st.Stage == "" {
+		st.Stage = "awake"
+	}
+	return st, nil
+}
+
+func isSleepStage(stage string) bool {
+	return stage == "light" || stage == "deep" || stage == "rem"
+}
+
+// condition is a parsed When expression: terms joined by && bind tighter
+// than ||, so it is held as a list of alternatives that each need every term.
+type condition struct {
+	src   string
+	anyOf [][]condTerm
+}
+
+// condTerm is one comparison, or a bare boolean variable when op is empty.
+type condTerm struct {
+	name   string
+	negate bool
+	op     string
+	dur    time.Duration
+	str    string
+}
+
+// Condition variables and their kinds.
+var condVars = map[string]string{
+	"presence":   "bool",
+	"asleep":     "bool",
+	"stage":      "stage",
+	"in_bed":     "duration",
+	"asleep_for": "duration",
+}
+
+// parseCondition parses expressions such as "presence",
+// "stage == deep && in_bed >= 1h" or "!presence || stage == out".
+func parseCondition(src string) (*condition, error) {
+	toks, err := lexCondition(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &condition{src: src}
+	var group []condTerm
+	for i := 0; i < len(toks); {
+		term, n, err := parseTerm(toks[i:])
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, term)
+		i += n
+		if i == len(toks) {
+			break
+		}
+		switch toks[i] {
+		case "&&":
+		case "||":
+			c.anyOf = append(c.anyOf, group)
+			group = nil
+		default:
+			return nil, fmt.Errorf("expected && or || before %q", toks[i])
+		}
+		i++
+		if i == len(toks) {
+			return nil, fmt.Errorf("expression ends with an operator")
+		}
+	}
+	if len(group) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	c.anyOf = append(c.anyOf, group)
+	return c, nil
+}
+
+func parseTerm(toks []string) (condTerm, int, error) {
+	t := condTerm{}
+	n := 0
+	if toks[0] == "!" {
+		t.negate = true
+		n++
+		if len(toks) == 1 {
+			return t, 0, fmt.Errorf("expected a variable after !")
+		}
+	}
+	t.name = toks[n]
+	kind, ok := condVars[t.name]
+	if !ok {
+		return t, 0, fmt.Errorf("unknown variable %q (allowed: presence, asleep, stage, in_bed, asleep_for)", t.name)
+	}
+	n++
+	if kind == "bool" {
+		return t, n, nil
+	}
+	if t.negate {
+		return t, 0, fmt.Errorf("! only applies to presence and asleep")
+	}
+	if n+1 >= len(toks) {
+		return t, 0, fmt.Errorf("%s needs a comparison, e.g. %s", t.name, condExample(kind))
+	}
+	t.op, t.str = toks[n], toks[n+1]
+	switch kind {
+	case "stage":
+		if t.op != "==" && t.op != "!=" {
+			return t, 0, fmt.Errorf("stage only supports == and !=")
+		}
+		if !sleepStages[t.str] {
+			return t, 0, fmt.Errorf("unknown stage %q (allowed: awake, light, deep, rem, out)", t.str)
+		}
+	case "duration":
+		switch t.op {
+		case "<", "<=", ">", ">=":
+		default:
+			return t, 0, fmt.Errorf("%s only supports <, <=, > and >=", t.name)
+		}
+		d, err := time.ParseDuration(t.str)
+		if err != nil || d < 0 {
+			return t, 0, fmt.Errorf("invalid duration %q", t.str)
+		}
+		t.dur = d
+	}
+	return t, n + 2, nil
+}
+
+func condExample(kind string) string {
+	if kind == "stage" {
+		return "stage == deep"
+	}
+	return "asleep_for >= 30m"
+}
+
+func lexCondition(src string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.HasPrefix(src[i:], "&&"), strings.HasPrefix(src[i:], "||"),
+			strings.HasPrefix(src[i:], "=="), strings.HasPrefix(src[i:], "!="),
+			strings.HasPrefix(src[i:], "<="), strings.HasPrefix(src[i:], ">="):
+			toks = append(toks, src[i:i+2])
+			i += 2
+		case c == '!' || c == '<' || c == '>':
+			toks = append(toks, string(c))
+			i++
+		case c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(src) && (src[j] == '_' || src[j] == '.' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			toks = append(toks, strings.ToLower(src[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q in condition", c)
+		}
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	return toks, nil
+}
+
+// eval reports whether st satisfies the condition at now.
+func (c *condition) eval(st SleepState, now time.Time) bool {
+	for _, group := range c.anyOf {
+		ok := true
+		for _, t := range group {
+			if !t.eval(st, now) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (t condTerm) eval(st SleepState, now time.Time) bool {
+	switch t.name {
+	case "presence":
+		return st.Presence != t.negate
+	case "asleep":
+		return (st.Presence && isSleepStage(st.Stage)) != t.negate
+	case "stage":
+		return (st.Stage == t.str) == (t.op == "==")
+	case "in_bed":
+		if !st.Presence {
+			return false
+		}
+		return compareDuration(now.Sub(st.InBedSince), t.op, t.dur)
+	case "asleep_for":
+		if !st.Presence || st.AsleepSince.IsZero() {
+			return false
+		}
+		return compareDuration(now.Sub(st.AsleepSince), t.op, t.dur)
+	}
+	return false
+}
+
+func compareDuration(d time.Duration, op string, want time.Duration) bool {
+	switch op {
+	case "<":
+		return d < want
+	case "<=":
+		return d <= want
+	case ">":
+		return d > want
+	default:
+		return d >= want
+	}
+}
+
+// hasConditions reports whether any item is driven by a When condition.
+func hasConditions(triggers []*trigger) bool {
+	for _, tr := range triggers {
+		if tr.cond != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// processConditions reads the sleep state and runs every condition item whose
+// expression holds. Each item fires at most once per sleep session, which the
+// journal keeps true across restarts. Like process, it only returns an error
+// when the runner must stop.
+func (r *Runner) processConditions(ctx context.Context, now time.Time, triggers []*trigger, executed map[string]time.Time) error {
+	intervals, err := r.Client.RecentIntervals(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Error("read sleep state failed", "error", err)
+		return nil
+	}
+	st, err := sleepStateFrom(intervals)
+	if err != nil {
+		log.Error("read sleep state failed", "error", err)
+		return nil
+	}
+	if st.Session == "" {
+		return nil
+	}
+	for i, tr := range triggers {
+		if tr.cond == nil || !tr.allows(now.In(r.Timezone)) || !tr.cond.eval(st, now) {
+			continue
+		}
+		f := Firing{At: now, Index: i, Item: r.Items[i], Session: st.Session}
+		key := firingKey(f)
+		if _, ok := executed[key]; ok {
+			continue
+		}
+		executed[key] = now
+		log.Info("condition met", "item", f.Item.Label(), "when", tr.cond.src, "session", st.Session, "stage", st.Stage)
+		if r.isPaused(now) {
+			r.record(f, ResultPaused, 0, nil)
+			continue
+		}
+		if err := r.runFiring(ctx, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) pollInterval() time.Duration {
+	if r.PollInterval <= 0 {
+		return DefaultPollInterval
+	}
+	return r.PollInterval
+}
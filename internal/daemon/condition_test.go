@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+)
+
+func TestParseCondition(t *testing.T) {
+	valid := []string{
+		"presence",
+		"!presence",
+		"asleep && stage == deep",
+		"asleep_for>=30m",
+		"stage != awake || in_bed > 1h30m",
+	}
+	for _, src := range valid {
+		if _, err := parseCondition(src); err != nil {
+			t.Errorf("%q: unexpected error: %v", src, err)
+		}
+	}
+	invalid := map[string]string{
+		"":                     "empty",
+		"snoring":              "unknown variable",
+		"stage == dreaming":    "unknown stage",
+		"stage > deep":         "only supports == and !=",
+		"in_bed == 1h":         "only supports <",
+		"asleep_for >= soon":   "invalid duration",
+		"asleep_for":           "needs a comparison",
+		"!stage == deep":       "! only applies",
+		"presence asleep":      "expected && or ||",
+		"presence &&":          "ends with an operator",
+		"presence && (asleep)": "unexpected",
+	}
+	for src, want := range invalid {
+		if _, err := parseCondition(src); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", src, want, err)
+		}
+	}
+}
+
+func TestSleepStateFromIntervals(t *testing.T) {
+	st, err := sleepStateFrom([]client.Interval{{
+		ID:         "s1",
+		Start:      "2024-01-01T22:00:00Z",
+		Incomplete: true,
+		Stages:     []client.Stage{{Stage: "awake", Duration: 900}, {Stage: "light", Duration: 1200}, {Stage: "deep", Duration: 600}},
+	}})
+	if err != nil {
+		t.Fatalf("state: %v", err)
+	}
+	asleep := time.Date(2024, 1, 1, 22, 15, 0, 0, time.UTC)
+	if st.Session != "s1" || !st.Presence || st.Stage != "deep" || !st.AsleepSince.Equal(asleep) {
+		t.Fatalf("unexpected state: %+v", st)
+	}
+	c, err := parseCondition("asleep && asleep_for >= 30m || stage == out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.eval(st, asleep.Add(29*time.Minute)) || !c.eval(st, asleep.Add(30*time.Minute)) {
+		t.Fatalf("asleep_for should turn true 30m after falling asleep")
+	}
+
+	st, err = sleepStateFrom([]client.Interval{{ID: "s0", Start: "2024-01-01T07:00:00Z"}})
+	if err != nil || st.Presence || st.Session != "s0" {
+		t.Fatalf("completed session should not count as presence: %+v, %v", st, err)
+	}
+	if c, _ := parseCondition("!presence"); !c.eval(st, time.Now()) {
+		t.Fatalf("expected !presence to hold after the session ended")
+	}
+}
+
+func TestRunnerFiresConditionsOncePerSession(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	start := time.Date(2024, 1, 1, 22, 0, 0, 0, loc)
+	clock := newFakeClock(start.Add(5 * time.Minute))
+	ctrl := &fakeController{}
+	session := func(id string, stages ...client.Stage) client.Interval {
+		return client.Interval{ID: id, Start: start.Format(time.RFC3339), Incomplete: true, Stages: stages}
+	}
+	ctrl.setIntervals(session("s1", client.Stage{Stage: "awake", Duration: 300}))
+	r := &Runner{
+		Items: []ScheduleItem{
+			{Name: "in-bed", When: "presence", Action: "temp", Temperature: "-20"},
+			{Name: "warm-up", When: "asleep_for >= 30m", Action: "temp", Temperature: "10"},
+			{Name: "weekends", When: "presence", Days: []string{"weekends"}, Action: "off"},
+		},
+		Client:       ctrl,
+		Timezone:     loc,
+		Clock:        clock,
+		PollInterval: time.Minute,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute) // 22:06: in bed, awake
+	ctrl.setIntervals(session("s1", client.Stage{Stage: "awake", Duration: 600}, client.Stage{Stage: "light", Duration: 600}))
+	clock.advance(20 * time.Minute) // 22:26: asleep since 22:10
+	clock.advance(15 * time.Minute) // 22:41: asleep for 31m
+	clock.advance(time.Minute)
+	ctrl.setIntervals(session("s2"))
+	clock.advance(time.Minute) // new session: presence fires again
+	clock.settle()
+	stop()
+	want := "temp -20,temp 10,temp -20"
+	if got := strings.Join(ctrl.Calls(), ","); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestConditionItemsAreNotPlanned(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	items := []ScheduleItem{{When: "presence", Action: "on"}}
+	firings, err := Plan(items, loc, time.Date(2024, 1, 1, 0, 0, 0, 0, loc), time.Date(2024, 1, 8, 0, 0, 0, 0, loc))
+	if err != nil || len(firings) != 0 {
+		t.Fatalf("expected no clock firings for a condition item, got %v, %v", firings, err)
+	}
+	if err := ValidateSchedule([]ScheduleItem{{Time: "22:00", When: "presence", Action: "on"}}, loc); err == nil {
+		t.Fatalf("expected error when both time and when are set")
+	}
+	if got := items[0].Label(); got != "when presence on" {
+		t.Fatalf("unexpected label %q", got)
+	}
+}
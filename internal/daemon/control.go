@@ -0,0 +1,292 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Control commands understood by the daemon's socket.
+const (
+	ControlNext   = "next"
+	ControlPause  = "pause"
+	ControlResume = "resume"
+	ControlRun    = "run"
+)
+
+// oneShotLabel names actions injected through the control socket in logs and the journal.
+const oneShotLabel = "one-off"
+
+// ControlRequest is a single JSON request sent over the control socket.
+type ControlRequest struct {
+	Command string `json:"command"`
+	// Until ends a pause; zero pauses for tonight (until the next noon).
+	Until time.Time `json:"until,omitzero"`
+	// Action, Temperature, Duration and At describe a one-time action for
+	// "run"; a zero At runs it immediately. alarm-one-off is not available.
+	Action      string    `json:"action,omitempty"`
+	Temperature string    `json:"temperature,omitempty"`
+	Duration    string    `json:"duration,omitempty"`
+	At          time.Time `json:"at,omitzero"`
+}
+
+// ControlFiring describes a firing in a control response.
+type ControlFiring struct {
+	At          time.Time `json:"at"`
+	Item        string    `json:"item"`
+	Action      string    `json:"action"`
+	Temperature string    `json:"temperature,omitempty"`
+}
+
+// ControlResponse is the daemon's reply to a ControlRequest.
+type ControlResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	// Next is the next firing that will run, if any.
+	Next        *ControlFiring `json:"next,omitempty"`
+	PausedUntil time.Time      `json:"paused_until,omitzero"`
+	// Result is the journal result of an immediate one-time action.
+	Result string `json:"result,omitempty"`
+}
+
+type controlCall struct {
+	req   ControlRequest
+	reply chan ControlResponse
+}
+
+// serveControl listens on the control socket and forwards each request to
+// the run loop, which owns all runner state. With no socket configured the
+// returned channel is nil and never delivers.
+func (r *Runner) serveControl() (<-chan controlCall, func(), error) {
+	if r.Socket == "" {
+		return nil, func() {}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Socket), 0o755); err != nil {
+		return nil, nil, err
+	}
+	if conn, err := net.Dial("unix", r.Socket); err == nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("control socket %s is in use by another daemon", r.Socket)
+	}
+	// Nothing answers, so any file left here is from a daemon that died.
+	_ = os.Remove(r.Socket)
+	ln, err := net.Listen("unix", r.Socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listen on control socket: %w", err)
+	}
+	if err := os.Chmod(r.Socket, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, nil, err
+	}
+	calls := make(chan controlCall)
+	done := make(chan struct{})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveControlConn(conn, calls, done)
+		}
+	}()
+	return calls, func() {
+		close(done)
+		_ = ln.Close()
+		_ = os.Remove(r.Socket)
+	}, nil
+}
+
+func serveControlConn(conn net.Conn, calls chan<- controlCall, done <-chan struct{}) {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	var req ControlRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		_ = json.NewEncoder(conn).Encode(ControlResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	call := controlCall{req: req, reply: make(chan ControlResponse, 1)}
+	select {
+	case calls <- call:
+	case <-done:
+		return
+	}
+	select {
+	case res := <-call.reply:
+		_ = json.NewEncoder(conn).Encode(res)
+	case <-done:
+	}
+}
+
+// answer replies to one control request, from the run loop or while an
+// action waits to retry.
+func (r *Runner) answer(ctx context.Context, call controlCall) {
+	call.reply <- r.handleControl(ctx, call.req, r.triggers)
+	r.updateNext(r.triggers, r.clock().Now())
+}
+
+// handleControl applies a control request inside the run loop.
+func (r *Runner) handleControl(ctx context.Context, req ControlRequest, triggers []*trigger) ControlResponse {
+	now := r.clock().Now()
+	r.expirePause(now)
+	switch req.Command {
+	case ControlNext:
+	case ControlPause:
+		until := req.Until
+		if until.IsZero() {
+			until = endOfNight(now, r.Timezone)
+		}
+		if !until.After(now) {
+			return ControlResponse{Error: "pause end must be in the future"}
+		}
+		r.pausedUntil = until
+		log.Info("schedule paused", "until", until.Format(time.RFC3339))
+	case ControlResume:
+		if !r.pausedUntil.IsZero() {
+			log.Info("schedule resumed")
+		}
+		r.pausedUntil = time.Time{}
+	case ControlRun:
+		f := Firing{At: req.At, Index: -1, Item: ScheduleItem{Name: oneShotLabel, Action: req.Action, Temperature: req.Temperature, Duration: req.Duration}}
+		if err := f.Item.validateAction(); err != nil {
+			return ControlResponse{Error: err.Error()}
+		}
+		if f.Item.Action == "temp" {
+			if _, err := r.temps().Parse(f.Item.Temperature); err != nil {
+				return ControlResponse{Error: err.Error()}
+			}
+		}
+		if f.At.After(now) {
+			r.oneShots = append(r.oneShots, f)
+			sort.SliceStable(r.oneShots, func(a, b int) bool { return r.oneShots[a].At.Before(r.oneShots[b].At) })
+			log.Info("one-time action scheduled", "action", f.Item.Action, "at", f.At.Format(time.RFC3339))
+			return ControlResponse{OK: true, Next: controlFiring(f), PausedUntil: r.pausedUntil}
+		}
+		f.At = now
+		res := ControlResponse{OK: true, Result: ResultOK, PausedUntil: r.pausedUntil}
+		if r.DryRun {
+			r.dryRun(f)
+			res.Result = ResultDryRun
+		} else if err := r.runNow(ctx, f); err != nil {
+			res.OK, res.Result, res.Error = false, ResultError, err.Error()
+		}
+		return res
+	default:
+		return ControlResponse{Error: fmt.Sprintf("unknown command %q", req.Command)}
+	}
+	return ControlResponse{OK: true, Next: r.nextFiring(triggers, now), PausedUntil: r.pausedUntil}
+}
+
+// runNow makes a single attempt at an immediate one-time action so the
+// client waiting on the socket gets the real result well within its timeout.
+// The outcome is journaled, but a failure is left to the client to report
+// rather than counted against the failure policy.
+func (r *Runner) runNow(ctx context.Context, f Firing) error {
+	err := r.execute(ctx, f.Item)
+	if err != nil {
+		r.record(f, ResultError, 1, err)
+		log.Error("one-time action failed", "action", f.Item.Action, "error", err)
+		return err
+	}
+	r.record(f, ResultOK, 1, nil)
+	return nil
+}
+
+// nextFiring returns the first firing after now that will actually run,
+// skipping any that fall inside a pause.
+func (r *Runner) nextFiring(triggers []*trigger, now time.Time) *ControlFiring {
+	var next *Firing
+	from := now
+	if r.pausedUntil.After(from) {
+		from = r.pausedUntil.Add(-time.Nanosecond)
+	}
+	for i, tr := range triggers {
+		t := tr.next(from, r.Timezone)
+		if t.IsZero() {
+			continue
+		}
+		if next == nil || t.Before(next.At) {
+			next = &Firing{At: t, Index: i, Item: r.Items[i]}
+		}
+	}
+	if len(r.oneShots) > 0 && (next == nil || r.oneShots[0].At.Before(next.At)) {
+		next = &r.oneShots[0]
+	}
+	if next == nil {
+		return nil
+	}
+	return controlFiring(*next)
+}
+
+func controlFiring(f Firing) *ControlFiring {
+	return &ControlFiring{At: f.At, Item: f.Item.Label(), Action: f.Item.Action, Temperature: f.Item.Temperature}
+}
+
+// takeOneShots removes and returns injected actions due at or before now.
+func (r *Runner) takeOneShots(now time.Time) []Firing {
+	n := 0
+	for n < len(r.oneShots) && !r.oneShots[n].At.After(now) {
+		n++
+	}
+	due := r.oneShots[:n:n]
+	r.oneShots = r.oneShots[n:]
+	return due
+}
+
+func (r *Runner) isPaused(t time.Time) bool {
+	return t.Before(r.pausedUntil)
+}
+
+func (r *Runner) expirePause(now time.Time) {
+	if !r.pausedUntil.IsZero() && !now.Before(r.pausedUntil) {
+		log.Info("schedule pause ended")
+		r.pausedUntil = time.Time{}
+	}
+}
+
+// endOfNight is the next local noon: pausing "for tonight" covers the coming
+// night including the morning wake-up.
+func endOfNight(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	noon := time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, loc)
+	if !noon.After(local) {
+		noon = time.Date(local.Year(), local.Month(), local.Day()+1, 12, 0, 0, 0, loc)
+	}
+	return noon
+}
+
+// SendControl sends one request to the daemon listening on socket. A
+// response with ok=false is returned together with its error.
+func SendControl(ctx context.Context, socket string, req ControlRequest) (*ControlResponse, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socket)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("%w (no control socket at %s)", ErrNotRunning, socket)
+		}
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var res ControlResponse
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return nil, fmt.Errorf("read control response: %w", err)
+	}
+	if !res.OK {
+		return &res, errors.New(res.Error)
+	}
+	return &res, nil
+}
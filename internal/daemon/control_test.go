@@ -0,0 +1,152 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// controlSocket returns a short socket path; sun_path is limited to ~100 bytes.
+func controlSocket(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, "d.sock")
+}
+
+func sendControl(t *testing.T, socket string, req ControlRequest) *ControlResponse {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var res *ControlResponse
+	var err error
+	// The socket appears once Run has started.
+	for i := 0; i < 100; i++ {
+		if res, err = SendControl(ctx, socket, req); !errors.Is(err, ErrNotRunning) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("%s: %v", req.Command, err)
+	}
+	return res
+}
+
+func TestControlSocket(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	socket := controlSocket(t)
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 50, 0, 0, loc))
+	ctrl := &fakeController{}
+	r := &Runner{
+		Items: []ScheduleItem{
+			{Name: "bedtime", Time: "22:00", Action: "on"},
+			{Time: "07:00", Action: "off"},
+		},
+		Client:   ctrl,
+		Timezone: loc,
+		Clock:    clock,
+		Socket:   socket,
+	}
+	stop := runWithClock(t, r)
+
+	res := sendControl(t, socket, ControlRequest{Command: ControlNext})
+	if res.Next == nil || res.Next.Item != "bedtime" || !res.Next.At.Equal(time.Date(2024, 1, 1, 22, 0, 0, 0, loc)) {
+		t.Fatalf("unexpected next: %+v", res.Next)
+	}
+
+	// Pausing for tonight lasts until noon and skips both firings.
+	res = sendControl(t, socket, ControlRequest{Command: ControlPause})
+	if want := time.Date(2024, 1, 2, 12, 0, 0, 0, loc); !res.PausedUntil.Equal(want) {
+		t.Fatalf("paused until %v, want %v", res.PausedUntil, want)
+	}
+	if res.Next == nil || !res.Next.At.Equal(time.Date(2024, 1, 2, 22, 0, 0, 0, loc)) {
+		t.Fatalf("next while paused: %+v", res.Next)
+	}
+	clock.advance(11 * time.Minute) // 22:01
+	clock.settle()
+	if got := ctrl.Calls(); len(got) != 0 {
+		t.Fatalf("paused schedule ran: %v", got)
+	}
+
+	// An immediate one-time action runs even while paused.
+	res = sendControl(t, socket, ControlRequest{Command: ControlRun, Action: "temp", Temperature: "-10"})
+	if res.Result != ResultOK {
+		t.Fatalf("run result: %+v", res)
+	}
+	// A scheduled one becomes the next firing.
+	at := time.Date(2024, 1, 1, 22, 30, 0, 0, loc)
+	res = sendControl(t, socket, ControlRequest{Command: ControlRun, Action: "off", At: at})
+	if res.Next == nil || res.Next.Item != oneShotLabel || !res.Next.At.Equal(at) {
+		t.Fatalf("scheduled one-off: %+v", res.Next)
+	}
+	if res := sendControl(t, socket, ControlRequest{Command: ControlResume}); !res.PausedUntil.IsZero() || res.Next == nil || !res.Next.At.Equal(at) {
+		t.Fatalf("resume: %+v", res)
+	}
+	clock.advance(30 * time.Minute) // 22:31
+	clock.settle()
+
+	ctx := context.Background()
+	if _, err := SendControl(ctx, socket, ControlRequest{Command: ControlRun, Action: "dance"}); err == nil || !strings.Contains(err.Error(), "unknown action") {
+		t.Fatalf("expected invalid action error, got %v", err)
+	}
+	if _, err := SendControl(ctx, socket, ControlRequest{Command: "reboot"}); err == nil {
+		t.Fatalf("expected unknown command error")
+	}
+	stop()
+
+	want := []string{"temp -10", "off"}
+	if got := ctrl.Calls(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if _, err := os.Stat(socket); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("socket not removed on exit: %v", err)
+	}
+	if _, err := SendControl(ctx, socket, ControlRequest{Command: ControlNext}); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning after exit, got %v", err)
+	}
+}
+
+func TestControlRunTriesOnce(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	socket := controlSocket(t)
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 50, 0, 0, loc))
+	ctrl := &fakeController{failures: 1}
+	policy, err := ParseFailurePolicy("exit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Runner{
+		Client:       ctrl,
+		Timezone:     loc,
+		Clock:        clock,
+		Socket:       socket,
+		Retries:      5,
+		RetryBackoff: time.Hour,
+		OnFailure:    policy,
+	}
+	stop := runWithClock(t, r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sendControl(t, socket, ControlRequest{Command: ControlNext})
+	res, err := SendControl(ctx, socket, ControlRequest{Command: ControlRun, Action: "on"})
+	if err == nil || res.Result != ResultError {
+		t.Fatalf("expected the failed attempt to be reported, got %+v, %v", res, err)
+	}
+	// The failure is the client's to handle: the runner keeps serving.
+	if res := sendControl(t, socket, ControlRequest{Command: ControlRun, Action: "off"}); res.Result != ResultOK {
+		t.Fatalf("second run: %+v", res)
+	}
+	stop()
+	if got := ctrl.Calls(); strings.Join(got, ",") != "on,off" {
+		t.Fatalf("expected one attempt each, got %v", got)
+	}
+}
@@ -2,182 +2,548 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+	"github.com/charmbracelet/log"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
 )
 
-// ScheduleItem describes a timed action.
+// ScheduleItem describes a timed action. Exactly one of Time (daily HH:MM),
+// Cron (5-field expression) or When (a sleep condition) is required; Days and
+// From/Until narrow when it fires. See Actions for the supported actions and
+// their parameters.
 type ScheduleItem struct {
-	Time        string `mapstructure:"time" yaml:"time"`
-	Action      string `mapstructure:"action" yaml:"action"`
-	Temperature string `mapstructure:"temperature" yaml:"temperature"`
+	Name string `mapstructure:"name" yaml:"name,omitempty"`
+	Time string `mapstructure:"time" yaml:"time,omitempty"`
+	Cron string `mapstructure:"cron" yaml:"cron,omitempty"`
+	// When fires the item once per sleep session as soon as the expression
+	// holds, e.g. "presence" or "asleep_for >= 30m".
+	When        string   `mapstructure:"when" yaml:"when,omitempty"`
+	Days        []string `mapstructure:"days" yaml:"days,omitempty"`
+	From        string   `mapstructure:"from" yaml:"from,omitempty"`
+	Until       string   `mapstructure:"until" yaml:"until,omitempty"`
+	Action      string   `mapstructure:"action" yaml:"action"`
+	Temperature string   `mapstructure:"temperature" yaml:"temperature,omitempty"`
+	// Duration limits a temp action (Go duration, e.g. "2h"); it is applied
+	// through the device endpoint and the level reverts when it ends.
+	Duration string `mapstructure:"duration" yaml:"duration,omitempty"`
+	// Alarm configures an alarm-one-off action.
+	Alarm *ScheduleAlarm `mapstructure:"alarm" yaml:"alarm,omitempty"`
+	// CatchUp decides what happens to firings missed by more than Grace
+	// (suspend, slow API calls): skip (default), run-latest or run-all.
+	CatchUp string `mapstructure:"catch_up" yaml:"catch_up,omitempty"`
+	Grace   string `mapstructure:"grace" yaml:"grace,omitempty"`
+}
+
+// Catch-up policies for missed firings.
+const (
+	CatchUpSkip   = "skip"
+	CatchUpLatest = "run-latest"
+	CatchUpAll    = "run-all"
+)
+
+// DefaultGrace is how late a firing may run and still count as on time.
+const DefaultGrace = 2 * time.Minute
+
+// DefaultRetryBackoff is the delay before the first retry of a failed action.
+const DefaultRetryBackoff = 30 * time.Second
+
+const maxRetryBackoff = 5 * time.Minute
+
+// Failure policy modes.
+const (
+	FailureContinue  = "continue"
+	FailureExit      = "exit"
+	FailureExitAfter = "exit-after"
+)
+
+// FailurePolicy decides what the runner does once an action has failed all
+// its retries: keep going, stop, or stop after Limit consecutive failures.
+type FailurePolicy struct {
+	Mode  string
+	Limit int
+}
+
+// ParseFailurePolicy accepts "continue", "exit" or "exit-after N"
+// (also "exit-after=N").
+func ParseFailurePolicy(s string) (FailurePolicy, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", FailureContinue:
+		return FailurePolicy{Mode: FailureContinue}, nil
+	case FailureExit:
+		return FailurePolicy{Mode: FailureExit, Limit: 1}, nil
+	}
+	if rest, ok := strings.CutPrefix(s, FailureExitAfter); ok {
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), "="))
+		n, err := strconv.Atoi(rest)
+		if err != nil || n <= 0 {
+			return FailurePolicy{}, fmt.Errorf("invalid failure policy %q: exit-after needs a positive count", s)
+		}
+		return FailurePolicy{Mode: FailureExitAfter, Limit: n}, nil
+	}
+	return FailurePolicy{}, fmt.Errorf("invalid failure policy %q (allowed: continue, exit, exit-after N)", s)
+}
+
+// String renders the policy in the form ParseFailurePolicy accepts.
+func (p FailurePolicy) String() string {
+	switch p.Mode {
+	case FailureExitAfter:
+		return fmt.Sprintf("%s %d", FailureExitAfter, p.Limit)
+	case FailureExit:
+		return FailureExit
+	default:
+		return FailureContinue
+	}
+}
+
+// exits reports whether consecutive failures reach the policy's limit.
+func (p FailurePolicy) exits(consecutive int) bool {
+	switch p.Mode {
+	case FailureExit:
+		return consecutive >= 1
+	case FailureExitAfter:
+		return consecutive >= p.Limit
+	default:
+		return false
+	}
+}
+
+// Label identifies the item in logs and plans: its name, or its trigger and action.
+func (s ScheduleItem) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	when := s.Time
+	if s.Cron != "" {
+		when = s.Cron
+	}
+	if s.When != "" {
+		when = "when " + s.When
+	}
+	return strings.TrimSpace(when + " " + s.Action)
 }
 
 // Runner executes scheduled items.
 type Runner struct {
 	Items    []ScheduleItem
-	Client   *client.Client
+	Client   Controller
 	Timezone *time.Location
 	DryRun   bool
-	Sync     bool
-	PIDFile  string
+	// Sync periodically reconciles the device with the schedule's current state.
+	Sync bool
+	// SyncInterval defaults to DefaultSyncInterval.
+	SyncInterval time.Duration
+	PIDFile      string
+	// Temps converts schedule temperatures to heating levels; defaults to tempconv.DefaultTable.
+	Temps tempconv.Table
+	// Grace applies to items without their own grace; defaults to DefaultGrace.
+	Grace time.Duration
+	// Clock defaults to the wall clock.
+	Clock Clock
+	// Journal, when set, records every handled firing and lets a restarted
+	// runner skip firings that already ran.
+	Journal *Journal
+	// Retries is how many times a failed action is retried after its first attempt.
+	Retries int
+	// RetryBackoff is the first retry delay, doubling per attempt; defaults to DefaultRetryBackoff.
+	RetryBackoff time.Duration
+	// OnFailure decides whether a failed action stops the runner; defaults to continue.
+	OnFailure FailurePolicy
+	// Reload, when set, re-reads the schedule on SIGHUP or when ConfigFile
+	// changes.
+	Reload func() (ScheduleConfig, error)
+	// ConfigFile is checked for changes on every tick when Reload is set.
+	ConfigFile string
+
+	// Socket, when set, is the path of the Unix control socket.
+	Socket string
+	// PollInterval is how often sleep data is read for items with a When
+	// condition; defaults to DefaultPollInterval.
+	PollInterval time.Duration
+	// Metrics, when set, counts handled actions and tracks the next action
+	// time and the bed level, read from the device every PollInterval.
+	Metrics *Metrics
+	// Listen, when set, serves /healthz and /metrics on this address; Metrics
+	// is created if nil.
+	Listen string
+
+	failures    int
+	pausedUntil time.Time
+	oneShots    []Firing
+	triggers    []*trigger
+	control     <-chan controlCall
 }
 
 func (r *Runner) Run(ctx context.Context) error {
-	if err := r.writePID(); err != nil {
+	var err error
+	if r.triggers, err = compileSchedule(r.Items, r.Timezone); err != nil {
 		return err
 	}
-	defer r.removePID()
-
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
+	if r.PIDFile != "" {
+		lock, err := AcquirePID(r.PIDFile)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+	}
 
-	executed := map[string]bool{}
-	day := time.Now().Day()
+	clock := r.clock()
+	r.failures = 0
+	last := clock.Now()
+	executed, err := r.loadExecuted(last)
+	if err != nil {
+		return err
+	}
+	if r.Journal != nil {
+		// The journal guards against repeats, so a restart can still pick up
+		// firings that fell due just before it within the grace window.
+		last = last.Add(-r.grace())
+	}
+	ticks, stop := clock.NewTicker(time.Minute)
+	defer stop()
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	// A signal cancels ctx rather than waiting for the loop, so it also cuts
+	// short a retry backoff or a request in flight.
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	hup := make(chan os.Signal, 1)
+	if r.Reload != nil {
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+	}
+	stamp, _ := statFile(r.ConfigFile)
+	var lastSync, lastPoll, lastStatus time.Time
+	r.pausedUntil, r.oneShots = time.Time{}, nil
+	control, closeControl, err := r.serveControl()
+	if err != nil {
+		return err
+	}
+	defer closeControl()
+	r.control = control
+	if r.Listen != "" && r.Metrics == nil {
+		r.Metrics = NewMetrics()
+	}
+	closeMetrics, err := r.serveMetrics()
+	if err != nil {
+		return err
+	}
+	defer closeMetrics()
+	r.Metrics.tick(clock.Now())
+	r.updateNext(r.triggers, clock.Now())
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-sig:
-			return nil
-		case now := <-ticker.C:
-			if now.Day() != day {
-				executed = map[string]bool{}
-				day = now.Day()
+		case <-hup:
+			r.triggers = r.reload(r.triggers, "SIGHUP")
+			r.updateNext(r.triggers, clock.Now())
+		case call := <-r.control:
+			r.answer(ctx, call)
+		case now := <-ticks:
+			r.Metrics.tick(now)
+			if r.Reload != nil && r.configChanged(&stamp) {
+				r.triggers = r.reload(r.triggers, "config file changed")
 			}
-			if err := r.process(now, executed); err != nil {
+			if err := r.process(ctx, now, last, r.triggers, executed); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
 				return err
 			}
+			last = now
+			if r.Metrics != nil && now.Sub(lastStatus) >= r.pollInterval() {
+				lastStatus = now
+				r.refreshBedLevel(ctx)
+			}
+			if hasConditions(r.triggers) && now.Sub(lastPoll) >= r.pollInterval() {
+				lastPoll = now
+				if err := r.processConditions(ctx, now, r.triggers, executed); err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+			}
+			pruneExecuted(executed, now, r.Timezone)
+			r.updateNext(r.triggers, now)
+			if r.Sync && !r.isPaused(now) && now.Sub(lastSync) >= r.syncInterval() {
+				lastSync = now
+				if err := r.reconcile(ctx, r.triggers, now); err != nil && ctx.Err() == nil {
+					log.Error("state sync failed", "error", err)
+				}
+			}
 		}
 	}
 }
 
-func (r *Runner) process(now time.Time, executed map[string]bool) error {
-	for _, item := range r.Items {
-		t, err := time.ParseInLocation("15:04", item.Time, r.Timezone)
-		if err != nil {
-			return fmt.Errorf("parse time %s: %w", item.Time, err)
-		}
-		candidate := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, r.Timezone)
-		if now.Before(candidate) || now.Sub(candidate) >= time.Minute {
+// process runs every firing scheduled in (last, now], applying each item's
+// catch-up policy, then any injected one-time actions that have come due. It
+// only returns an error when the failure policy says to stop.
+func (r *Runner) process(ctx context.Context, now, last time.Time, triggers []*trigger, executed map[string]time.Time) error {
+	r.expirePause(now)
+	for _, f := range r.due(planCompiled(r.Items, triggers, r.Timezone, last, now), now, triggers) {
+		key := firingKey(f)
+		if _, ok := executed[key]; ok {
 			continue
 		}
-		key := candidate.Format("2006-01-02 15:04") + item.Action
-		if executed[key] {
+		executed[key] = f.At
+		if r.isPaused(f.At) {
+			log.Info("schedule paused; skipping firing", "item", f.Item.Label(), "scheduled", f.At.Format(time.RFC3339), "paused_until", r.pausedUntil.Format(time.RFC3339))
+			r.record(f, ResultPaused, 0, nil)
 			continue
 		}
-		executed[key] = true
-		if r.DryRun {
-			fmt.Printf("DRY-RUN %s %s %s\n", candidate.Format(time.RFC3339), item.Action, item.Temperature)
-			continue
+		if err := r.runFiring(ctx, f); err != nil {
+			return err
 		}
-		switch item.Action {
-		case "on":
-			if err := r.Client.TurnOn(context.Background()); err != nil {
-				return err
-			}
-		case "off":
-			if err := r.Client.TurnOff(context.Background()); err != nil {
-				return err
-			}
-		case "temp":
-			level, err := ParseTemp(item.Temperature)
-			if err != nil {
-				return err
-			}
-			if err := r.Client.SetTemperature(context.Background(), level); err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("unknown action %s", item.Action)
+	}
+	for _, f := range r.takeOneShots(now) {
+		if err := r.runFiring(ctx, f); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func (r *Runner) writePID() error {
-	if r.PIDFile == "" {
+// runFiring executes (or, in dry-run mode, prints) one firing and journals
+// the outcome. It only returns an error when the runner must stop, either
+// because ctx ended or the failure policy says so.
+func (r *Runner) runFiring(ctx context.Context, f Firing) error {
+	item := f.Item
+	if r.DryRun {
+		r.dryRun(f)
 		return nil
 	}
-	if err := os.MkdirAll(filepath.Dir(r.PIDFile), 0o755); err != nil {
-		return err
+	attempts, err := r.executeWithRetry(ctx, f)
+	if err == nil {
+		r.failures = 0
+		r.record(f, ResultOK, attempts, nil)
+		return nil
 	}
-	if data, err := os.ReadFile(r.PIDFile); err == nil {
-		pid := strings.TrimSpace(string(data))
-		if pid != "" {
-			return fmt.Errorf("daemon already running (pid %s)", pid)
+	r.record(f, ResultError, attempts, err)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	r.failures++
+	log.Error("action failed", "item", item.Label(), "action", item.Action, "scheduled", f.At.Format(time.RFC3339), "attempts", attempts, "consecutive_failures", r.failures, "error", err)
+	if r.OnFailure.exits(r.failures) {
+		return fmt.Errorf("stopping after %d consecutive failures (on-failure %s): %s: %w", r.failures, r.OnFailure, item.Label(), err)
+	}
+	return nil
+}
+
+func (r *Runner) dryRun(f Firing) {
+	fmt.Printf("DRY-RUN %s %s %s\n", f.At.Format(time.RFC3339), f.Item.Action, f.Item.Temperature)
+	r.record(f, ResultDryRun, 0, nil)
+}
+
+// executeWithRetry runs the firing's action, retrying with exponential
+// backoff. It returns the number of attempts made.
+func (r *Runner) executeWithRetry(ctx context.Context, f Firing) (int, error) {
+	delay := r.retryBackoff()
+	for attempt := 1; ; attempt++ {
+		err := r.execute(ctx, f.Item)
+		if err == nil || ctx.Err() != nil || attempt > r.Retries || errors.Is(err, errInvalidAction) {
+			return attempt, err
 		}
+		r.Metrics.actionRetry()
+		log.Warn("action failed, retrying", "item", f.Item.Label(), "action", f.Item.Action, "attempt", attempt, "retry_in", delay, "error", err)
+		if err := r.backoff(ctx, delay); err != nil {
+			return attempt, err
+		}
+		delay = min(delay*2, maxRetryBackoff)
 	}
-	return os.WriteFile(r.PIDFile, []byte(fmt.Sprint(os.Getpid())), 0o600)
 }
 
-func (r *Runner) removePID() {
-	if r.PIDFile != "" {
-		_ = os.Remove(r.PIDFile)
+// backoff waits d before a retry, still answering control requests so the
+// socket stays responsive. It returns ctx's error if ctx ends first, which a
+// SIGTERM or SIGINT also causes.
+func (r *Runner) backoff(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case call := <-r.control:
+			r.answer(ctx, call)
+		}
 	}
 }
 
-// parseTempLevel converts "68F" or "20C" to heating level approximation.
-// ParseTemp converts user input temperature string to heating level.
-func ParseTemp(s string) (int, error) {
-	s = strings.TrimSpace(strings.ToUpper(s))
-	if strings.HasSuffix(s, "F") {
-		v := strings.TrimSuffix(s, "F")
-		var f float64
-		_, err := fmt.Sscanf(v, "%f", &f)
-		if err != nil {
-			return 0, err
+// record appends the outcome of f to the journal. A journal write failure is
+// logged rather than returned so a full disk does not stop the schedule.
+func (r *Runner) record(f Firing, result string, attempts int, err error) {
+	r.Metrics.action(f.Item.Action, result)
+	if r.Journal == nil {
+		return
+	}
+	entry := JournalEntry{
+		Time:        r.clock().Now(),
+		Scheduled:   f.At,
+		Key:         firingKey(f),
+		Item:        f.Item.Label(),
+		Action:      f.Item.Action,
+		Temperature: f.Item.Temperature,
+		Result:      result,
+		Attempts:    attempts,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if werr := r.Journal.Append(entry); werr != nil {
+		log.Error("write journal", "path", r.Journal.Path, "error", werr)
+	}
+}
+
+// loadExecuted seeds the dedupe set with firings the journal shows completed
+// since the start of yesterday; failed and dry-run firings may run again.
+func (r *Runner) loadExecuted(now time.Time) (map[string]time.Time, error) {
+	// executed maps firing keys to their scheduled time so entries can be pruned.
+	executed := map[string]time.Time{}
+	if r.Journal == nil {
+		return executed, nil
+	}
+	local := now.In(r.Timezone)
+	since := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, r.Timezone)
+	entries, err := r.Journal.Read(since)
+	if err != nil {
+		return nil, fmt.Errorf("load journal: %w", err)
+	}
+	for _, e := range entries {
+		if e.Result == ResultOK && e.Key != "" {
+			executed[e.Key] = e.Scheduled
 		}
-		return mapFtoLevel(f), nil
 	}
-	if strings.HasSuffix(s, "C") {
-		v := strings.TrimSuffix(s, "C")
-		var c float64
-		_, err := fmt.Sscanf(v, "%f", &c)
-		if err != nil {
-			return 0, err
+	return executed, nil
+}
+
+// due filters firings down to those that should run now. Firings within the
+// grace window always run; older ones follow the item's catch-up policy, and a
+// missed run-latest firing is dropped if a newer firing of the item is on time.
+func (r *Runner) due(firings []Firing, now time.Time, triggers []*trigger) []Firing {
+	out := make([]Firing, 0, len(firings))
+	latest := map[int]int{} // item index -> position of its pending missed firing in out
+	drop := map[int]bool{}
+	for _, f := range firings {
+		tr := triggers[f.Index]
+		grace := tr.grace
+		if grace == 0 {
+			grace = r.grace()
+		}
+		if now.Sub(f.At) <= grace {
+			if pos, ok := latest[f.Index]; ok {
+				drop[pos] = true
+				delete(latest, f.Index)
+			}
+			out = append(out, f)
+			continue
+		}
+		switch tr.catchUp {
+		case CatchUpAll:
+			out = append(out, f)
+		case CatchUpLatest:
+			if pos, ok := latest[f.Index]; ok {
+				drop[pos] = true
+			}
+			latest[f.Index] = len(out)
+			out = append(out, f)
+		default:
+			log.Warn("skipping missed firing", "item", f.Item.Label(), "scheduled", f.At.Format(time.RFC3339), "late", now.Sub(f.At).Round(time.Second))
+			r.record(f, ResultSkipped, 0, nil)
+		}
+	}
+	if len(drop) == 0 {
+		return out
+	}
+	kept := out[:0]
+	for i, f := range out {
+		if !drop[i] {
+			kept = append(kept, f)
 		}
-		return mapCtoLevel(c), nil
 	}
-	var lvl int
-	if _, err := fmt.Sscanf(s, "%d", &lvl); err == nil {
-		return lvl, nil
+	return kept
+}
+
+// firingKey identifies a firing across restarts. It uses the item label rather
+// than its position so reordering the schedule does not re-run firings.
+func firingKey(f Firing) string {
+	if f.Session != "" {
+		// Condition firings happen once per sleep session, whenever they fire.
+		return "session:" + f.Session + "#" + f.Item.Label() + "#" + f.Item.Action
+	}
+	// RFC3339 keeps the zone offset, so the repeated hour when DST ends stays distinct.
+	return f.At.Format(time.RFC3339) + "#" + f.Item.Label() + "#" + f.Item.Action
+}
+
+// pruneExecuted forgets firings scheduled before the start of yesterday in loc.
+func pruneExecuted(executed map[string]time.Time, now time.Time, loc *time.Location) {
+	local := now.In(loc)
+	cutoff := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, loc)
+	for key, at := range executed {
+		if at.Before(cutoff) {
+			delete(executed, key)
+		}
+	}
+}
+
+// updateNext publishes the next scheduled action to the metrics.
+func (r *Runner) updateNext(triggers []*trigger, now time.Time) {
+	if r.Metrics == nil {
+		return
 	}
-	return 0, fmt.Errorf("temperature must end with F/C or be level")
+	var next time.Time
+	if f := r.nextFiring(triggers, now); f != nil {
+		next = f.At
+	}
+	r.Metrics.setNextAction(next)
 }
 
-// Simple linear approximations; Eight Sleep internals are non-linear, but this keeps UX consistent.
-func mapFtoLevel(f float64) int {
-	// Rough map 55F -> -100, 100F -> 100.
-	scaled := (f-55)/(100-55)*200 - 100
-	if scaled < -100 {
-		scaled = -100
+func (r *Runner) clock() Clock {
+	if r.Clock == nil {
+		return realClock{}
 	}
-	if scaled > 100 {
-		scaled = 100
+	return r.Clock
+}
+
+func (r *Runner) retryBackoff() time.Duration {
+	if r.RetryBackoff <= 0 {
+		return DefaultRetryBackoff
 	}
-	return int(scaled)
+	return r.RetryBackoff
 }
 
-func mapCtoLevel(c float64) int {
-	// 13C ~ 55F, 38C ~ 100F
-	scaled := (c-13)/(38-13)*200 - 100
-	if scaled < -100 {
-		scaled = -100
+func (r *Runner) grace() time.Duration {
+	if r.Grace <= 0 {
+		return DefaultGrace
 	}
-	if scaled > 100 {
-		scaled = 100
+	return r.Grace
+}
+
+func (r *Runner) temps() tempconv.Table {
+	if len(r.Temps) == 0 {
+		return tempconv.DefaultTable
 	}
-	return int(scaled)
+	return r.Temps
 }
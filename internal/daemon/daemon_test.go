@@ -0,0 +1,451 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+)
+
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	ticks   chan time.Time
+	ready   chan struct{}
+	stopped chan struct{}
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, ticks: make(chan time.Time), ready: make(chan struct{}), stopped: make(chan struct{})}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker signals readiness: the runner has read its start time by now.
+func (c *fakeClock) NewTicker(time.Duration) (<-chan time.Time, func()) {
+	close(c.ready)
+	return c.ticks, func() { close(c.stopped) }
+}
+
+// advance moves the clock forward and delivers a tick; it blocks until the
+// runner receives it, so the previous tick has been fully processed. It
+// returns false if the runner has already stopped.
+func (c *fakeClock) advance(d time.Duration) bool {
+	<-c.ready
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	c.mu.Unlock()
+	select {
+	case c.ticks <- now:
+		return true
+	case <-c.stopped:
+		return false
+	}
+}
+
+// settle delivers an empty tick; once it is received the previous tick has
+// been fully processed, so the test can change inputs without racing it.
+func (c *fakeClock) settle() { c.advance(0) }
+
+type fakeController struct {
+	mu    sync.Mutex
+	calls []string
+	// failures makes that many calls fail before succeeding.
+	failures int
+	// off and level simulate the device state GetStatus reports.
+	off   bool
+	level int
+	// intervals is what RecentIntervals reports.
+	intervals []client.Interval
+}
+
+func (f *fakeController) RecentIntervals(context.Context) ([]client.Interval, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]client.Interval(nil), f.intervals...), nil
+}
+
+func (f *fakeController) setIntervals(intervals ...client.Interval) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.intervals = intervals
+}
+
+func (f *fakeController) record(s string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, s)
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("api PUT: 503 Service Unavailable")
+	}
+	return nil
+}
+
+func (f *fakeController) TurnOn(context.Context) error {
+	return f.apply("on", func() { f.off = false })
+}
+
+func (f *fakeController) TurnOff(context.Context) error {
+	return f.apply("off", func() { f.off = true })
+}
+
+func (f *fakeController) SetLevel(_ context.Context, level int, duration int) error {
+	call := fmt.Sprintf("temp %d", level)
+	if duration > 0 {
+		call += fmt.Sprintf(" for %ds", duration)
+	}
+	return f.apply(call, func() { f.level = level })
+}
+
+// apply records the call and, if it succeeds, updates the simulated device.
+func (f *fakeController) apply(call string, update func()) error {
+	if err := f.record(call); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	update()
+	return nil
+}
+
+func (f *fakeController) NapActivate(context.Context) error { return f.record("nap-on") }
+func (f *fakeController) NapDeactivate(context.Context) error {
+	return f.record("nap-off")
+}
+func (f *fakeController) HotFlashActivate(context.Context) error {
+	return f.record("hotflash-on")
+}
+func (f *fakeController) HotFlashDeactivate(context.Context) error {
+	return f.record("hotflash-off")
+}
+func (f *fakeController) SetOneOffAlarm(_ context.Context, a client.OneOffAlarm) error {
+	return f.record(fmt.Sprintf("alarm %s vib=%t/%d thermal=%t/%d", a.Time, a.VibrationEnabled, a.VibrationLevel, a.ThermalEnabled, a.ThermalLevel))
+}
+
+func (f *fakeController) GetStatus(context.Context) (*client.TempStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st := &client.TempStatus{CurrentLevel: f.level}
+	st.CurrentState.Type = "smart"
+	if f.off {
+		st.CurrentState.Type = "off"
+	}
+	return st, nil
+}
+
+// set changes the simulated device as if from the app, bypassing the call log.
+func (f *fakeController) set(off bool, level int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.off, f.level = off, level
+}
+
+func (f *fakeController) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// runWithClock starts the runner and returns a stop func that waits for Run to exit.
+func runWithClock(t *testing.T, r *Runner) func() {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+	return func() {
+		t.Helper()
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+}
+
+func TestRunnerFiresOnTime(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 58, 30, 0, loc))
+	ctrl := &fakeController{}
+	r := &Runner{
+		Items:    []ScheduleItem{{Time: "22:00", Action: "on"}},
+		Client:   ctrl,
+		Timezone: loc,
+		Clock:    clock,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute)
+	clock.advance(time.Minute)
+	clock.advance(time.Minute)
+	stop()
+	if got := ctrl.Calls(); len(got) != 1 || got[0] != "on" {
+		t.Fatalf("expected single on, got %v", got)
+	}
+}
+
+func TestRunnerCatchUpPolicies(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	items := func(policy string) []ScheduleItem {
+		return []ScheduleItem{{Cron: "0 * * * *", Action: "temp", Temperature: "10", CatchUp: policy}}
+	}
+	tests := []struct {
+		policy string
+		want   int
+	}{
+		{CatchUpSkip, 0},
+		{"", 0},
+		{CatchUpLatest, 1},
+		{CatchUpAll, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			// Simulate a suspend: the next tick arrives at 03:50, after the
+			// 01:00, 02:00 and 03:00 firings have all outlived their grace.
+			clock := newFakeClock(time.Date(2024, 1, 1, 0, 30, 0, 0, loc))
+			ctrl := &fakeController{}
+			r := &Runner{Items: items(tt.policy), Client: ctrl, Timezone: loc, Clock: clock}
+			stop := runWithClock(t, r)
+			clock.advance(3*time.Hour + 20*time.Minute)
+			stop()
+			if got := ctrl.Calls(); len(got) != tt.want {
+				t.Fatalf("policy %q: expected %d calls, got %v", tt.policy, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunnerGraceWindow(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 59, 0, 0, loc))
+	ctrl := &fakeController{}
+	r := &Runner{
+		Items:    []ScheduleItem{{Time: "22:00", Action: "off", Grace: "10m"}},
+		Client:   ctrl,
+		Timezone: loc,
+		Clock:    clock,
+	}
+	stop := runWithClock(t, r)
+	// A slow tick lands 8 minutes late: still inside the item's grace.
+	clock.advance(9 * time.Minute)
+	stop()
+	if got := ctrl.Calls(); len(got) != 1 {
+		t.Fatalf("expected firing within grace, got %v", got)
+	}
+}
+
+func TestRunnerRunLatestSupersededByOnTime(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	firings := []Firing{
+		{At: time.Date(2024, 1, 1, 1, 0, 0, 0, loc), Index: 0},
+		{At: time.Date(2024, 1, 1, 2, 0, 0, 0, loc), Index: 0},
+	}
+	triggers := []*trigger{{catchUp: CatchUpLatest}}
+	r := &Runner{Timezone: loc}
+	got := r.due(firings, time.Date(2024, 1, 1, 2, 1, 0, 0, loc), triggers)
+	if len(got) != 1 || got[0].At.Hour() != 2 {
+		t.Fatalf("expected only the on-time firing, got %v", got)
+	}
+}
+
+func TestRunnerDSTDayBoundary(t *testing.T) {
+	loc := mustLoc(t, "America/New_York")
+	// DST ends 2024-11-03; 01:30 local happens twice.
+	clock := newFakeClock(time.Date(2024, 11, 3, 0, 59, 0, 0, loc))
+	ctrl := &fakeController{}
+	r := &Runner{
+		Items:    []ScheduleItem{{Cron: "30 1 * * *", Action: "on"}, {Time: "23:59", Action: "off"}},
+		Client:   ctrl,
+		Timezone: loc,
+		Clock:    clock,
+	}
+	stop := runWithClock(t, r)
+	for i := 0; i < 24*60; i++ {
+		clock.advance(time.Minute)
+	}
+	stop()
+	// The cron fires once on the first 01:30; the daily item fires once at 23:59 local.
+	got := ctrl.Calls()
+	if len(got) != 2 || got[0] != "on" || got[1] != "off" {
+		t.Fatalf("unexpected calls across DST end: %v", got)
+	}
+}
+
+func TestPruneExecuted(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	now := time.Date(2024, 1, 3, 0, 5, 0, 0, loc)
+	executed := map[string]time.Time{
+		"old":       time.Date(2024, 1, 1, 23, 0, 0, 0, loc),
+		"yesterday": time.Date(2024, 1, 2, 1, 0, 0, 0, loc),
+	}
+	pruneExecuted(executed, now, loc)
+	if _, ok := executed["old"]; ok {
+		t.Fatalf("expected old entry pruned")
+	}
+	if _, ok := executed["yesterday"]; !ok {
+		t.Fatalf("expected yesterday's entry kept")
+	}
+}
+
+func TestParseFailurePolicy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want FailurePolicy
+	}{
+		{"", FailurePolicy{Mode: FailureContinue}},
+		{"continue", FailurePolicy{Mode: FailureContinue}},
+		{"EXIT", FailurePolicy{Mode: FailureExit, Limit: 1}},
+		{"exit-after 3", FailurePolicy{Mode: FailureExitAfter, Limit: 3}},
+		{"exit-after=2", FailurePolicy{Mode: FailureExitAfter, Limit: 2}},
+	}
+	for _, tt := range tests {
+		got, err := ParseFailurePolicy(tt.in)
+		if err != nil || got != tt.want {
+			t.Fatalf("ParseFailurePolicy(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"exit-after", "exit-after 0", "retry"} {
+		if _, err := ParseFailurePolicy(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestRunnerRetriesFailedAction(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 59, 30, 0, loc))
+	ctrl := &fakeController{failures: 2}
+	r := &Runner{
+		Items:        []ScheduleItem{{Time: "22:00", Action: "on"}},
+		Client:       ctrl,
+		Timezone:     loc,
+		Clock:        clock,
+		Retries:      2,
+		RetryBackoff: time.Millisecond,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute)
+	clock.advance(time.Minute) // received only once the retries are done
+	stop()
+	if got := ctrl.Calls(); len(got) != 3 {
+		t.Fatalf("expected 3 attempts, got %v", got)
+	}
+}
+
+func TestRunnerFailurePolicies(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	items := []ScheduleItem{
+		{Time: "22:00", Action: "on"},
+		{Time: "22:01", Action: "temp", Temperature: "-20"},
+		{Time: "22:02", Action: "off"},
+	}
+	tests := []struct {
+		policy  string
+		wantErr bool
+		calls   int
+	}{
+		{"continue", false, 3},
+		{"exit", true, 1},
+		{"exit-after 2", true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			policy, err := ParseFailurePolicy(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clock := newFakeClock(time.Date(2024, 1, 1, 21, 59, 30, 0, loc))
+			ctrl := &fakeController{failures: 100}
+			r := &Runner{Items: items, Client: ctrl, Timezone: loc, Clock: clock, OnFailure: policy}
+			done := make(chan error, 1)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { done <- r.Run(ctx) }()
+			for i := 0; i < 3 && clock.advance(time.Minute); i++ {
+			}
+			cancel()
+			err = <-done
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("policy %s: err = %v", tt.policy, err)
+			}
+			if got := ctrl.Calls(); len(got) != tt.calls {
+				t.Fatalf("policy %s: expected %d calls, got %v", tt.policy, tt.calls, got)
+			}
+		})
+	}
+}
+
+func TestRunnerRetryHonorsContext(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 59, 30, 0, loc))
+	ctrl := &fakeController{failures: 100}
+	r := &Runner{
+		Items:        []ScheduleItem{{Time: "22:00", Action: "on"}},
+		Client:       ctrl,
+		Timezone:     loc,
+		Clock:        clock,
+		Retries:      5,
+		RetryBackoff: time.Hour,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute)
+	// Run is now blocked in backoff; cancelling must return promptly without error.
+	stop()
+	if got := ctrl.Calls(); len(got) != 1 {
+		t.Fatalf("expected 1 attempt before cancel, got %v", got)
+	}
+}
+
+func TestRunnerStopsDuringRetryBackoff(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	socket := controlSocket(t)
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 59, 30, 0, loc))
+	ctrl := &fakeController{failures: 100}
+	r := &Runner{
+		Items:        []ScheduleItem{{Time: "22:00", Action: "on"}, {Time: "23:00", Action: "off"}},
+		Client:       ctrl,
+		Timezone:     loc,
+		Clock:        clock,
+		Socket:       socket,
+		Retries:      5,
+		RetryBackoff: time.Hour,
+	}
+	done := make(chan error, 1)
+	go func() { done <- r.Run(context.Background()) }()
+	clock.advance(time.Minute)
+
+	// The runner is now waiting an hour to retry; control still answers.
+	res := sendControl(t, socket, ControlRequest{Command: ControlNext})
+	if res.Next == nil || res.Next.Action != "off" {
+		t.Fatalf("next during backoff: %+v", res.Next)
+	}
+
+	// daemon stop sends SIGTERM, which must end the wait promptly.
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := self.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runner did not stop during retry backoff")
+	}
+	if got := ctrl.Calls(); len(got) != 1 {
+		t.Fatalf("expected 1 attempt before stop, got %v", got)
+	}
+}
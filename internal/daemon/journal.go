@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Journal results.
+const (
+	ResultOK      = "ok"
+	ResultError   = "error"
+	ResultDryRun  = "dry-run"
+	ResultSkipped = "skipped"
+	ResultPaused  = "paused"
+)
+
+// JournalEntry records one firing the runner handled.
+type JournalEntry struct {
+	Time        time.Time `json:"time"`
+	Scheduled   time.Time `json:"scheduled"`
+	Key         string    `json:"key"`
+	Item        string    `json:"item"`
+	Action      string    `json:"action"`
+	Temperature string    `json:"temperature,omitempty"`
+	Result      string    `json:"result"`
+	Attempts    int       `json:"attempts,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Journal is an append-only JSON-lines log of daemon activity.
+type Journal struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewJournal returns a journal stored at path.
+func NewJournal(path string) *Journal {
+	return &Journal{Path: path}
+}
+
+// Append writes e as a single line; each write opens the file in append mode
+// so entries survive crashes and concurrent readers see whole lines.
+func (j *Journal) Append(e JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0o755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns entries recorded at or after since, oldest first. A missing
+// journal is empty; malformed lines (e.g. a torn final write) are skipped.
+func (j *Journal) Read(since time.Time) ([]JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.Open(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []JournalEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	entries := []JournalEntry{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		var e JournalEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			log.Debug("skipping malformed journal line", "path", j.Path, "line", n, "error", err)
+			continue
+		}
+		if e.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	return entries, nil
+}
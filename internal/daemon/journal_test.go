@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalAppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "journal.jsonl")
+	j := NewJournal(path)
+	base := time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)
+	for i, result := range []string{ResultOK, ResultError} {
+		if err := j.Append(JournalEntry{Time: base.Add(time.Duration(i) * time.Hour), Item: "bedtime", Action: "on", Result: result}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	// A torn trailing write must not hide earlier entries.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_, _ = f.WriteString(`{"time":"2024-01`)
+	_ = f.Close()
+
+	all, err := j.Read(time.Time{})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(all) != 2 || all[0].Result != ResultOK || all[1].Result != ResultError {
+		t.Fatalf("unexpected entries: %+v", all)
+	}
+	recent, err := j.Read(base.Add(30 * time.Minute))
+	if err != nil {
+		t.Fatalf("read since: %v", err)
+	}
+	if len(recent) != 1 {
+		t.Fatalf("expected 1 recent entry, got %d", len(recent))
+	}
+}
+
+func TestJournalReadMissing(t *testing.T) {
+	entries, err := NewJournal(filepath.Join(t.TempDir(), "none.jsonl")).Read(time.Time{})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty journal, got %v, %v", entries, err)
+	}
+}
+
+func TestRunnerJournalDedupesAcrossRestart(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	items := []ScheduleItem{{Name: "bedtime", Time: "22:00", Action: "on"}}
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 59, 30, 0, loc))
+	first := &fakeController{}
+	stop := runWithClock(t, &Runner{Items: items, Client: first, Timezone: loc, Clock: clock, Journal: journal})
+	clock.advance(time.Minute)
+	clock.advance(time.Minute)
+	stop()
+
+	// Restart 30s later: 22:00 is inside the look-back window but already ran.
+	clock = newFakeClock(time.Date(2024, 1, 1, 22, 1, 0, 0, loc))
+	second := &fakeController{}
+	stop = runWithClock(t, &Runner{Items: items, Client: second, Timezone: loc, Clock: clock, Journal: journal})
+	clock.advance(time.Minute)
+	clock.advance(time.Minute)
+	stop()
+
+	if got := first.Calls(); len(got) != 1 {
+		t.Fatalf("first run: expected 1 call, got %v", got)
+	}
+	if got := second.Calls(); len(got) != 0 {
+		t.Fatalf("restart repeated action: %v", got)
+	}
+	entries, err := journal.Read(time.Time{})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Result != ResultOK || entries[0].Item != "bedtime" {
+		t.Fatalf("unexpected journal: %+v", entries)
+	}
+}
+
+func TestRunnerJournalRetriesFailedAfterRestart(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	at := time.Date(2024, 1, 1, 22, 0, 0, 0, loc)
+	f := Firing{At: at, Item: ScheduleItem{Time: "22:00", Action: "on"}}
+	if err := journal.Append(JournalEntry{Time: at, Scheduled: at, Key: firingKey(f), Item: f.Item.Label(), Action: "on", Result: ResultError, Error: "boom"}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	clock := newFakeClock(at.Add(30 * time.Second))
+	ctrl := &fakeController{}
+	stop := runWithClock(t, &Runner{Items: []ScheduleItem{f.Item}, Client: ctrl, Timezone: loc, Clock: clock, Journal: journal})
+	clock.advance(time.Minute)
+	clock.advance(time.Minute)
+	stop()
+	if got := ctrl.Calls(); len(got) != 1 {
+		t.Fatalf("expected failed firing to be retried, got %v", got)
+	}
+}
@@ -0,0 +1,238 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/client"
+)
+
+// healthyTickAge is how long the runner may go without ticking before
+// /healthz reports it unhealthy.
+const healthyTickAge = 3 * time.Minute
+
+// Metrics collects daemon counters and gauges and serves them in the
+// Prometheus text format. It also implements client.Observer so API errors,
+// retries and token refreshes are counted where they happen.
+type Metrics struct {
+	mu             sync.Mutex
+	actions        map[[2]string]int // {action, result}
+	apiErrors      map[int]int       // status code; 0 for network errors
+	apiRetries     int
+	actionRetries  int
+	tokenRefreshes int
+	bedLevel       *int
+	nextAction     time.Time
+	lastTick       time.Time
+}
+
+var _ client.Observer = (*Metrics)(nil)
+
+// NewMetrics returns an empty collector.
+func NewMetrics() *Metrics {
+	return &Metrics{actions: map[[2]string]int{}, apiErrors: map[int]int{}}
+}
+
+func (m *Metrics) APIError(status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiErrors[status]++
+}
+
+func (m *Metrics) Retry() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiRetries++
+}
+
+func (m *Metrics) TokenRefresh() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokenRefreshes++
+}
+
+// The runner's hooks below are no-ops on a nil *Metrics.
+
+func (m *Metrics) action(action, result string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.actions[[2]string{action, result}]++
+}
+
+func (m *Metrics) actionRetry() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.actionRetries++
+}
+
+func (m *Metrics) setBedLevel(level int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bedLevel = &level
+}
+
+func (m *Metrics) setNextAction(t time.Time) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextAction = t
+}
+
+func (m *Metrics) tick(now time.Time) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastTick = now
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cw := &countingWriter{w: w}
+
+	header(cw, "eightsleep_daemon_actions_total", "counter", "Schedule firings handled, by action and result.")
+	keys := make([][2]string, 0, len(m.actions))
+	for k := range m.actions {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(cw, "eightsleep_daemon_actions_total{action=%q,result=%q} %d\n", k[0], k[1], m.actions[k])
+	}
+
+	header(cw, "eightsleep_api_errors_total", "counter", "Failed API responses by HTTP status (\"network\" for transport errors).")
+	codes := make([]int, 0, len(m.apiErrors))
+	for c := range m.apiErrors {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+	for _, c := range codes {
+		status := strconv.Itoa(c)
+		if c == 0 {
+			status = "network"
+		}
+		fmt.Fprintf(cw, "eightsleep_api_errors_total{status=%q} %d\n", status, m.apiErrors[c])
+	}
+
+	header(cw, "eightsleep_api_retries_total", "counter", "API requests retried by the client.")
+	fmt.Fprintf(cw, "eightsleep_api_retries_total %d\n", m.apiRetries)
+	header(cw, "eightsleep_daemon_action_retries_total", "counter", "Failed schedule actions retried by the daemon.")
+	fmt.Fprintf(cw, "eightsleep_daemon_action_retries_total %d\n", m.actionRetries)
+	header(cw, "eightsleep_token_refreshes_total", "counter", "Access tokens obtained from the auth server.")
+	fmt.Fprintf(cw, "eightsleep_token_refreshes_total %d\n", m.tokenRefreshes)
+
+	if m.bedLevel != nil {
+		header(cw, "eightsleep_bed_level", "gauge", "Heating level last read from the device or set by the daemon (-100..100).")
+		fmt.Fprintf(cw, "eightsleep_bed_level %d\n", *m.bedLevel)
+	}
+	if !m.nextAction.IsZero() {
+		header(cw, "eightsleep_daemon_next_action_timestamp_seconds", "gauge", "Unix time of the next scheduled action.")
+		fmt.Fprintf(cw, "eightsleep_daemon_next_action_timestamp_seconds %d\n", m.nextAction.Unix())
+	}
+	return cw.n, cw.err
+}
+
+func header(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// countingWriter remembers the first error so WriteTo can report it once.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// Handler serves /healthz, which fails once the runner stops ticking, and /metrics.
+func (m *Metrics) Handler(clock Clock) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		last := m.lastTick
+		m.mu.Unlock()
+		if age := clock.Now().Sub(last); last.IsZero() || age > healthyTickAge {
+			http.Error(w, fmt.Sprintf("runner has not ticked for %s", age.Round(time.Second)), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = m.WriteTo(w)
+	})
+	return mux
+}
+
+// refreshBedLevel reads the device so the bed level gauge follows changes
+// made outside the daemon; a failed read keeps the previous value.
+func (r *Runner) refreshBedLevel(ctx context.Context) {
+	status, err := r.Client.GetStatus(ctx)
+	if err != nil {
+		log.Warn("read bed level", "error", err)
+		return
+	}
+	r.Metrics.setBedLevel(status.CurrentLevel)
+}
+
+// serveMetrics starts the HTTP server on r.Listen; the returned function
+// shuts it down.
+func (r *Runner) serveMetrics() (func(), error) {
+	if r.Listen == "" {
+		return func() {}, nil
+	}
+	ln, err := net.Listen("tcp", r.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", r.Listen, err)
+	}
+	srv := &http.Server{Handler: r.Metrics.Handler(r.clock()), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("metrics server stopped", "error", err)
+		}
+	}()
+	log.Info("serving health and metrics", "addr", ln.Addr().String())
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
@@ -0,0 +1,139 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunnerMetrics(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 59, 30, 0, loc))
+	ctrl := &fakeController{failures: 1}
+	m := NewMetrics()
+	r := &Runner{
+		Items: []ScheduleItem{
+			{Time: "22:00", Action: "temp", Temperature: "-30"},
+			{Time: "23:00", Action: "off"},
+		},
+		Client:       ctrl,
+		Timezone:     loc,
+		Clock:        clock,
+		Retries:      1,
+		RetryBackoff: time.Millisecond,
+		Metrics:      m,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute)
+	clock.settle()
+	stop()
+	m.APIError(503)
+	m.APIError(0)
+	m.Retry()
+
+	var b strings.Builder
+	if _, err := m.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	next := time.Date(2024, 1, 1, 23, 0, 0, 0, loc).Unix()
+	for _, want := range []string{
+		`eightsleep_daemon_actions_total{action="temp",result="ok"} 1`,
+		`eightsleep_daemon_action_retries_total 1`,
+		`eightsleep_api_errors_total{status="503"} 1`,
+		`eightsleep_api_errors_total{status="network"} 1`,
+		`eightsleep_api_retries_total 1`,
+		`eightsleep_token_refreshes_total 0`,
+		`eightsleep_bed_level -30`,
+		fmt.Sprintf("eightsleep_daemon_next_action_timestamp_seconds %d", next),
+		"# TYPE eightsleep_bed_level gauge",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC))
+	m := NewMetrics()
+	srv := httptest.NewServer(m.Handler(clock))
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if code, _ := get("/healthz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before the first tick, got %d", code)
+	}
+	m.tick(clock.Now())
+	if code, body := get("/healthz"); code != http.StatusOK || body != "ok\n" {
+		t.Fatalf("expected healthy, got %d %q", code, body)
+	}
+	clock.mu.Lock()
+	clock.now = clock.now.Add(healthyTickAge + time.Second)
+	clock.mu.Unlock()
+	if code, _ := get("/healthz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 once ticks stop, got %d", code)
+	}
+	if code, body := get("/metrics"); code != http.StatusOK || !strings.Contains(body, "eightsleep_api_retries_total 0") {
+		t.Fatalf("unexpected /metrics: %d %q", code, body)
+	}
+}
+
+func TestRunnerMetricsPollsBedLevel(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, loc))
+	ctrl := &fakeController{level: 15}
+	m := NewMetrics()
+	r := &Runner{
+		Items:        []ScheduleItem{{Time: "22:00", Action: "off"}},
+		Client:       ctrl,
+		Timezone:     loc,
+		Clock:        clock,
+		PollInterval: 2 * time.Minute,
+		Metrics:      m,
+	}
+	gauge := func() string {
+		var b strings.Builder
+		if _, err := m.WriteTo(&b); err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(b.String(), "\n") {
+			if strings.HasPrefix(line, "eightsleep_bed_level ") {
+				return line
+			}
+		}
+		return ""
+	}
+	stop := runWithClock(t, r)
+	defer stop()
+	clock.advance(time.Minute)
+	clock.settle()
+	if got := gauge(); got != "eightsleep_bed_level 15" {
+		t.Fatalf("expected level read on the first tick, got %q", got)
+	}
+	// Changed in the app: picked up on the next poll, not before.
+	ctrl.set(false, -40)
+	clock.advance(time.Minute)
+	clock.settle()
+	if got := gauge(); got != "eightsleep_bed_level 15" {
+		t.Fatalf("expected no read before the poll interval, got %q", got)
+	}
+	clock.advance(time.Minute)
+	clock.settle()
+	if got := gauge(); got != "eightsleep_bed_level -40" {
+		t.Fatalf("expected polled level, got %q", got)
+	}
+}
@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotRunning is returned when no live daemon holds the PID file.
+var ErrNotRunning = errors.New("daemon not running")
+
+// AlreadyRunningError reports a live daemon holding the PID file.
+type AlreadyRunningError struct {
+	PID int
+}
+
+func (e *AlreadyRunningError) Error() string {
+	return fmt.Sprintf("daemon already running (pid %d)", e.PID)
+}
+
+// PIDLock is a PID file the running daemon keeps locked for its lifetime.
+// The lock dies with the process, so a file left behind by a crash is stale
+// rather than a reason to refuse to start.
+type PIDLock struct {
+	path string
+	f    *os.File
+}
+
+// AcquirePID locks path and records the current process ID in it. It fails
+// with *AlreadyRunningError if another live process holds the lock.
+func AcquirePID(path string) (*PIDLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		locked, err := tryLock(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if !locked {
+			pid, _ := readPID(f)
+			_ = f.Close()
+			return nil, &AlreadyRunningError{PID: pid}
+		}
+		// A previous owner may have removed the file between our open and
+		// lock; the lock must be on the file that is still at path.
+		if !sameFile(f, path) {
+			_ = f.Close()
+			continue
+		}
+		if err := f.Truncate(0); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return &PIDLock{path: path, f: f}, nil
+	}
+}
+
+// Release removes the PID file and drops the lock.
+func (l *PIDLock) Release() {
+	if l == nil {
+		return
+	}
+	_ = os.Remove(l.path)
+	_ = l.f.Close()
+}
+
+// PIDStatus describes the daemon recorded in a PID file.
+type PIDStatus struct {
+	PID     int
+	Running bool
+	// Stale is set when the file exists but its process is gone.
+	Stale bool
+}
+
+// ReadPIDStatus inspects the PID file at path without disturbing a running daemon.
+func ReadPIDStatus(path string) (PIDStatus, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return PIDStatus{}, nil
+	}
+	if err != nil {
+		return PIDStatus{}, err
+	}
+	defer func() { _ = f.Close() }()
+	pid, err := readPID(f)
+	if err != nil {
+		return PIDStatus{}, err
+	}
+	running, err := lockHeld(f, pid)
+	if err != nil {
+		return PIDStatus{}, err
+	}
+	return PIDStatus{PID: pid, Running: running, Stale: !running}, nil
+}
+
+// StopDaemon signals the daemon recorded at path to shut down and waits up
+// to timeout for it to release the PID file. It returns the stopped PID.
+func StopDaemon(path string, timeout time.Duration) (int, error) {
+	st, err := ReadPIDStatus(path)
+	if err != nil {
+		return 0, err
+	}
+	if !st.Running {
+		return st.PID, ErrNotRunning
+	}
+	if err := terminate(st.PID); err != nil {
+		return st.PID, fmt.Errorf("signal pid %d: %w", st.PID, err)
+	}
+	pid := st.PID
+	deadline := time.Now().Add(timeout)
+	for {
+		cur, err := ReadPIDStatus(path)
+		if err != nil {
+			return pid, err
+		}
+		if !cur.Running {
+			return pid, nil
+		}
+		if time.Now().After(deadline) {
+			return pid, fmt.Errorf("daemon (pid %d) did not stop within %s", pid, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func readPID(f *os.File) (int, error) {
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 64))
+	if err != nil {
+		return 0, err
+	}
+	s := strings.TrimSpace(string(data))
+	if s == "" {
+		return 0, nil
+	}
+	pid, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pid file %s: %q", f.Name(), s)
+	}
+	return pid, nil
+}
+
+func sameFile(f *os.File, path string) bool {
+	a, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	b, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(a, b)
+}
@@ -0,0 +1,47 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package daemon
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// Without flock the PID file is not locked; liveness falls back to checking
+// whether the recorded process still exists.
+func tryLock(f *os.File) (bool, error) {
+	pid, err := readPID(f)
+	if err != nil || pid == 0 || pid == os.Getpid() {
+		return true, nil
+	}
+	alive, err := lockHeld(f, pid)
+	return !alive, err
+}
+
+func lockHeld(_ *os.File, pid int) (bool, error) {
+	if pid <= 0 {
+		return false, nil
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false, nil
+	}
+	defer func() { _ = p.Release() }()
+	if runtime.GOOS == "windows" {
+		// FindProcess only succeeds on Windows for a live process.
+		return true, nil
+	}
+	return p.Signal(syscall.Signal(0)) == nil, nil
+}
+
+func terminate(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		return p.Kill()
+	}
+	return p.Signal(syscall.SIGTERM)
+}
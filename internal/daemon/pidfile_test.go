@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestAcquirePIDExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "daemon.pid")
+	lock, err := AcquirePID(path)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("pid file = %q, %v", data, err)
+	}
+	st, err := ReadPIDStatus(path)
+	if err != nil || !st.Running || st.PID != os.Getpid() {
+		t.Fatalf("status while held = %+v, %v", st, err)
+	}
+	var running *AlreadyRunningError
+	if _, err := AcquirePID(path); !errors.As(err, &running) || running.PID != os.Getpid() {
+		t.Fatalf("expected AlreadyRunningError, got %v", err)
+	}
+	lock.Release()
+	if st, err := ReadPIDStatus(path); err != nil || st.Running || st.PID != 0 {
+		t.Fatalf("status after release = %+v, %v", st, err)
+	}
+}
+
+func TestAcquirePIDReplacesStaleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.pid")
+	// A crashed daemon leaves its PID behind without holding the lock.
+	if err := os.WriteFile(path, []byte("999999"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	st, err := ReadPIDStatus(path)
+	if runtime.GOOS != "windows" && (err != nil || st.Running || !st.Stale || st.PID != 999999) {
+		t.Fatalf("expected stale status, got %+v, %v", st, err)
+	}
+	if _, err := StopDaemon(path, time.Second); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("stop stale daemon: expected ErrNotRunning, got %v", err)
+	}
+	lock, err := AcquirePID(path)
+	if err != nil {
+		t.Fatalf("acquire over stale file: %v", err)
+	}
+	defer lock.Release()
+	data, _ := os.ReadFile(path)
+	if string(data) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("stale pid not replaced: %q", data)
+	}
+}
+
+// TestHelperHoldPID is not a real test: TestStopDaemon runs it in a child
+// process that holds the PID file until it receives SIGTERM.
+func TestHelperHoldPID(t *testing.T) {
+	path := os.Getenv("EIGHTSLEEP_TEST_PID_FILE")
+	if path == "" {
+		t.Skip("helper process")
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM)
+	lock, err := AcquirePID(path)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	_, _ = os.Stdout.WriteString("ready\n")
+	select {
+	case <-sig:
+	case <-time.After(30 * time.Second):
+	}
+	lock.Release()
+}
+
+func TestStopDaemon(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not deliverable on windows")
+	}
+	path := filepath.Join(t.TempDir(), "daemon.pid")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperHoldPID$")
+	cmd.Env = append(os.Environ(), "EIGHTSLEEP_TEST_PID_FILE="+path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill(); _ = cmd.Wait() })
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "ready\n" {
+		t.Fatalf("helper not ready: %q, %v", line, err)
+	}
+	pid, err := StopDaemon(path, 10*time.Second)
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if pid != cmd.Process.Pid {
+		t.Fatalf("stopped pid %d, want %d", pid, cmd.Process.Pid)
+	}
+	if st, _ := ReadPIDStatus(path); st.Running {
+		t.Fatalf("daemon still running after stop: %+v", st)
+	}
+}
@@ -0,0 +1,237 @@
+package daemon
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
+)
+
+// DefaultRampStep is how often a ramping profile changes the level.
+const DefaultRampStep = 10 * time.Minute
+
+// Profile is a nightly temperature curve made of phases (typically bedtime,
+// early-sleep, late-sleep and wake). The bed turns on at the first phase, each
+// phase moves the level to its target, linearly over Ramp when set, and End
+// turns the bed off. Profiles expand into ordinary schedule items (see
+// ExpandProfiles), so they share the journal, catch-up, sync and plan.
+type Profile struct {
+	Name  string   `mapstructure:"name" yaml:"name"`
+	Start string   `mapstructure:"start" yaml:"start"`
+	Days  []string `mapstructure:"days" yaml:"days,omitempty"`
+	From  string   `mapstructure:"from" yaml:"from,omitempty"`
+	Until string   `mapstructure:"until" yaml:"until,omitempty"`
+	// Step is the interval between level changes while ramping; defaults to DefaultRampStep.
+	Step string `mapstructure:"step" yaml:"step,omitempty"`
+	// End is an offset from Start that turns the bed off; empty leaves it on.
+	End    string         `mapstructure:"end" yaml:"end,omitempty"`
+	Phases []ProfilePhase `mapstructure:"phases" yaml:"phases"`
+}
+
+// ProfilePhase sets a target temperature at an offset from the profile start.
+type ProfilePhase struct {
+	Name        string `mapstructure:"name" yaml:"name"`
+	At          string `mapstructure:"at" yaml:"at"`
+	Temperature string `mapstructure:"temperature" yaml:"temperature"`
+	// Ramp spreads the change from the previous phase's level over this long,
+	// starting at At; empty jumps straight to the target.
+	Ramp string `mapstructure:"ramp" yaml:"ramp,omitempty"`
+}
+
+// ProfileStep is one action of an expanded profile, relative to its start.
+type ProfileStep struct {
+	Offset time.Duration
+	Phase  string
+	Action string
+	// Level is the heating level of a temp step.
+	Level int
+}
+
+// parseOffset parses a non-negative, whole-minute duration.
+func parseOffset(field, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 || d%time.Minute != 0 {
+		return 0, fmt.Errorf("invalid %s %q (use whole minutes, e.g. 1h30m)", field, s)
+	}
+	return d, nil
+}
+
+// Steps computes the profile's actions in order, converting temperatures with table.
+func (p Profile) Steps(table tempconv.Table) ([]ProfileStep, error) {
+	if len(p.Phases) == 0 {
+		return nil, fmt.Errorf("profile needs at least one phase")
+	}
+	step := DefaultRampStep
+	if p.Step != "" {
+		var err error
+		if step, err = parseOffset("step", p.Step); err != nil || step == 0 {
+			return nil, fmt.Errorf("invalid step %q (use whole minutes, e.g. 10m)", p.Step)
+		}
+	}
+	var (
+		steps  []ProfileStep
+		level  int
+		prevAt time.Duration
+		until  time.Duration
+	)
+	for i, ph := range p.Phases {
+		name := ph.Name
+		if name == "" {
+			name = fmt.Sprintf("phase-%d", i+1)
+		}
+		at, err := parseOffset("at", ph.At)
+		if err != nil {
+			return nil, fmt.Errorf("phase %s: %w", name, err)
+		}
+		ramp, err := parseOffset("ramp", ph.Ramp)
+		if err != nil {
+			return nil, fmt.Errorf("phase %s: %w", name, err)
+		}
+		if ph.Temperature == "" {
+			return nil, fmt.Errorf("phase %s: temperature is required", name)
+		}
+		target, err := table.Parse(ph.Temperature)
+		if err != nil {
+			return nil, fmt.Errorf("phase %s: temperature %q: %w", name, ph.Temperature, err)
+		}
+		if i == 0 {
+			if ramp > 0 {
+				return nil, fmt.Errorf("phase %s: the first phase cannot ramp", name)
+			}
+			steps = append(steps,
+				ProfileStep{Offset: at, Phase: name, Action: "on"},
+				ProfileStep{Offset: at, Phase: name, Action: "temp", Level: target})
+			level, prevAt, until = target, at, at
+			continue
+		}
+		if at <= prevAt || at < until {
+			return nil, fmt.Errorf("phase %s: at %s overlaps the previous phase", name, ph.At)
+		}
+		from := level
+		for t := step; t < ramp; t += step {
+			frac := float64(t) / float64(ramp)
+			l := int(math.Round(float64(from) + frac*float64(target-from)))
+			if l != level {
+				steps = append(steps, ProfileStep{Offset: at + t, Phase: name, Action: "temp", Level: l})
+				level = l
+			}
+		}
+		if target != level || ramp == 0 {
+			steps = append(steps, ProfileStep{Offset: at + ramp, Phase: name, Action: "temp", Level: target})
+		}
+		level, prevAt, until = target, at, at+ramp
+	}
+	if p.End != "" {
+		end, err := parseOffset("end", p.End)
+		if err != nil {
+			return nil, err
+		}
+		if end <= until {
+			return nil, fmt.Errorf("end %s must come after the last phase", p.End)
+		}
+		steps = append(steps, ProfileStep{Offset: end, Phase: "end", Action: "off"})
+		until = end
+	}
+	if until >= 24*time.Hour {
+		return nil, fmt.Errorf("profile spans %s; it must finish within 24h", until)
+	}
+	return steps, nil
+}
+
+// startItem is the schedule item that fires when the profile starts.
+func (p Profile) startItem() ScheduleItem {
+	return ScheduleItem{Name: p.Name, Time: p.Start, Days: p.Days, From: p.From, Until: p.Until, Action: "on"}
+}
+
+// NextStart returns the first start of the profile after t, or the zero time
+// when it will never run again.
+func (p Profile) NextStart(loc *time.Location, t time.Time) (time.Time, error) {
+	tr, err := p.startItem().trigger(loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("profile %s: %w", p.Name, err)
+	}
+	return tr.next(t, loc), nil
+}
+
+// ExpandProfiles turns profiles into schedule items named "<profile>/<phase>".
+// Steps that land after midnight fire on the following day, so Days and
+// From/Until shift with them.
+func ExpandProfiles(profiles []Profile, table tempconv.Table) ([]ScheduleItem, error) {
+	var items []ScheduleItem
+	seen := map[string]bool{}
+	for i, p := range profiles {
+		if p.Name == "" {
+			return nil, fmt.Errorf("profile %d: name is required", i+1)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("profile %s: duplicate name", p.Name)
+		}
+		seen[p.Name] = true
+		start, err := time.Parse("15:04", p.Start)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: parse start %q: %w", p.Name, p.Start, err)
+		}
+		days, err := parseDays(p.Days)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		steps, err := p.Steps(table)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		base := time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+		for _, s := range steps {
+			at := base + s.Offset
+			shift := int(at / (24 * time.Hour))
+			at %= 24 * time.Hour
+			item := p.startItem()
+			item.Name = p.Name + "/" + s.Phase
+			item.Time = fmt.Sprintf("%02d:%02d", int(at/time.Hour), int(at%time.Hour/time.Minute))
+			item.Action = s.Action
+			if s.Action == "temp" {
+				item.Temperature = strconv.Itoa(s.Level)
+			}
+			if shift > 0 {
+				item.Days = shiftDays(days, shift)
+				if item.From, err = shiftDate(p.From, shift); err != nil {
+					return nil, fmt.Errorf("profile %s: from: %w", p.Name, err)
+				}
+				if item.Until, err = shiftDate(p.Until, shift); err != nil {
+					return nil, fmt.Errorf("profile %s: until: %w", p.Name, err)
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// shiftDays moves a weekday mask forward by n days, as numeric days.
+func shiftDays(mask uint8, n int) []string {
+	if mask == 0 {
+		return nil
+	}
+	var out []string
+	for d := 0; d < 7; d++ {
+		if mask&(1<<uint((d-n%7+7)%7)) != 0 {
+			out = append(out, strconv.Itoa(d))
+		}
+	}
+	return out
+}
+
+func shiftDate(s string, n int) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return "", err
+	}
+	return d.AddDate(0, 0, n).Format(dateLayout), nil
+}
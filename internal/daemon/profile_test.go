@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
+)
+
+func TestProfileStepsRamp(t *testing.T) {
+	p := Profile{
+		Name:  "night",
+		Start: "22:30",
+		Step:  "10m",
+		End:   "8h",
+		Phases: []ProfilePhase{
+			{Name: "bedtime", Temperature: "-20"},
+			{Name: "early-sleep", At: "30m", Temperature: "-50", Ramp: "30m"},
+			{Name: "wake", At: "7h", Temperature: "10"},
+		},
+	}
+	steps, err := p.Steps(tempconv.DefaultTable)
+	if err != nil {
+		t.Fatalf("steps: %v", err)
+	}
+	type want struct {
+		off    time.Duration
+		action string
+		level  int
+	}
+	expected := []want{
+		{0, "on", 0},
+		{0, "temp", -20},
+		{40 * time.Minute, "temp", -30},
+		{50 * time.Minute, "temp", -40},
+		{time.Hour, "temp", -50},
+		{7 * time.Hour, "temp", 10},
+		{8 * time.Hour, "off", 0},
+	}
+	if len(steps) != len(expected) {
+		t.Fatalf("expected %d steps, got %+v", len(expected), steps)
+	}
+	for i, w := range expected {
+		s := steps[i]
+		if s.Offset != w.off || s.Action != w.action || s.Level != w.level {
+			t.Fatalf("step %d: expected %+v, got %+v", i, w, s)
+		}
+	}
+}
+
+func TestProfileStepsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		wantErr string
+	}{
+		{"no phases", Profile{}, "at least one phase"},
+		{"first ramp", Profile{Phases: []ProfilePhase{{Temperature: "0", Ramp: "10m"}}}, "first phase cannot ramp"},
+		{"missing temperature", Profile{Phases: []ProfilePhase{{Name: "bedtime"}}}, "temperature is required"},
+		{"bad temperature", Profile{Phases: []ProfilePhase{{Temperature: "warm"}}}, "temperature"},
+		{"seconds", Profile{Phases: []ProfilePhase{{Temperature: "0"}, {At: "90s", Temperature: "10"}}}, "whole minutes"},
+		{"overlap", Profile{Phases: []ProfilePhase{
+			{Temperature: "0"},
+			{At: "1h", Temperature: "10", Ramp: "1h"},
+			{At: "90m", Temperature: "20"},
+		}}, "overlaps"},
+		{"end too early", Profile{End: "30m", Phases: []ProfilePhase{{Temperature: "0"}, {At: "1h", Temperature: "10"}}}, "end"},
+		{"too long", Profile{Phases: []ProfilePhase{{Temperature: "0"}, {At: "25h", Temperature: "10"}}}, "24h"},
+		{"bad step", Profile{Step: "0m", Phases: []ProfilePhase{{Temperature: "0"}}}, "step"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.profile.Steps(tempconv.DefaultTable)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExpandProfilesCrossesMidnight(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	profiles := []Profile{{
+		Name:  "workweek",
+		Start: "23:00",
+		Days:  []string{"sat"},
+		Until: "2024-01-06",
+		Phases: []ProfilePhase{
+			{Name: "bedtime", Temperature: "-20"},
+			{Name: "wake", At: "7h", Temperature: "10"},
+		},
+	}}
+	items, err := ExpandProfiles(profiles, tempconv.DefaultTable)
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	wake := items[len(items)-1]
+	if wake.Name != "workweek/wake" || wake.Time != "06:00" || wake.Temperature != "10" {
+		t.Fatalf("unexpected wake item: %+v", wake)
+	}
+	if strings.Join(wake.Days, ",") != "0" || wake.Until != "2024-01-07" {
+		t.Fatalf("expected wake shifted to Sunday 2024-01-07, got %+v", wake)
+	}
+	// Saturday 2024-01-06 is the last night; its wake fires Sunday morning.
+	firings, err := Plan(items, loc, time.Date(2024, 1, 1, 0, 0, 0, 0, loc), time.Date(2024, 1, 31, 0, 0, 0, 0, loc))
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	var got []string
+	for _, f := range firings {
+		got = append(got, f.At.Format("01-02 15:04")+" "+f.Item.Action)
+	}
+	want := "01-06 23:00 on,01-06 23:00 temp,01-07 06:00 temp"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("expected %s, got %v", want, got)
+	}
+}
+
+func TestExpandProfilesRejectsDuplicates(t *testing.T) {
+	p := Profile{Name: "night", Start: "22:00", Phases: []ProfilePhase{{Temperature: "0"}}}
+	if _, err := ExpandProfiles([]Profile{p, p}, tempconv.DefaultTable); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("expected duplicate profile error, got %v", err)
+	}
+}
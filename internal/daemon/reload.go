@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
+)
+
+// fileStamp identifies a version of a file by size and modification time.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) (fileStamp, bool) {
+	if path == "" {
+		return fileStamp{}, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// configChanged reports whether ConfigFile differs from *stamp, updating it.
+// A file that disappears mid-save is not a change; its return is.
+func (r *Runner) configChanged(stamp *fileStamp) bool {
+	cur, ok := statFile(r.ConfigFile)
+	if !ok || cur == *stamp {
+		return false
+	}
+	*stamp = cur
+	return true
+}
+
+// ScheduleConfig is a freshly loaded schedule with the settings it was
+// validated against.
+type ScheduleConfig struct {
+	Items []ScheduleItem
+	// Timezone, when nil, keeps the current one.
+	Timezone *time.Location
+	// Temps, when empty, keeps the current table.
+	Temps tempconv.Table
+}
+
+// reload swaps in a freshly loaded schedule and returns its triggers. If the
+// new schedule cannot be loaded or compiled the current one stays in effect.
+func (r *Runner) reload(current []*trigger, reason string) []*trigger {
+	if r.Reload == nil {
+		return current
+	}
+	cfg, err := r.Reload()
+	items, loc := cfg.Items, cfg.Timezone
+	if loc == nil {
+		loc = r.Timezone
+	}
+	var triggers []*trigger
+	if err == nil {
+		triggers, err = compileSchedule(items, loc)
+	}
+	if err != nil {
+		log.Error("schedule reload failed; keeping current schedule", "reason", reason, "error", err)
+		return current
+	}
+	added, removed := diffSchedule(r.Items, items)
+	for _, item := range removed {
+		log.Info("schedule item removed", "item", item.Label(), "action", item.Action)
+	}
+	for _, item := range added {
+		log.Info("schedule item added", "item", item.Label(), "action", item.Action)
+	}
+	if loc.String() != r.Timezone.String() {
+		log.Info("schedule timezone changed", "from", r.Timezone.String(), "to", loc.String())
+	}
+	log.Info("schedule reloaded", "reason", reason, "items", len(items), "added", len(added), "removed", len(removed))
+	r.Items, r.Timezone = items, loc
+	if len(cfg.Temps) > 0 {
+		r.Temps = cfg.Temps
+	}
+	return triggers
+}
+
+// diffSchedule compares schedules item by item; an edited item shows up as
+// removed in its old form and added in its new one.
+func diffSchedule(old, cur []ScheduleItem) (added, removed []ScheduleItem) {
+	counts := map[string]int{}
+	for _, item := range old {
+		counts[itemKey(item)]++
+	}
+	for _, item := range cur {
+		k := itemKey(item)
+		if counts[k] > 0 {
+			counts[k]--
+			continue
+		}
+		added = append(added, item)
+	}
+	for _, item := range old {
+		k := itemKey(item)
+		if counts[k] > 0 {
+			counts[k]--
+			removed = append(removed, item)
+		}
+	}
+	return added, removed
+}
+
+func itemKey(s ScheduleItem) string {
+	alarm := ""
+	if s.Alarm != nil {
+		alarm = fmt.Sprintf("%+v", s.Alarm.oneOff())
+	}
+	return fmt.Sprintf("%q", []string{
+		s.Name, s.Time, s.Cron, s.When, fmt.Sprint(s.Days), s.From, s.Until,
+		s.Action, s.Temperature, s.Duration, alarm, s.CatchUp, s.Grace,
+	})
+}
@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/salmonumbrella/eightsleep-cli/internal/tempconv"
+)
+
+func TestDiffSchedule(t *testing.T) {
+	old := []ScheduleItem{
+		{Name: "bedtime", Time: "22:00", Action: "temp", Temperature: "68F"},
+		{Time: "07:00", Action: "off"},
+	}
+	cur := []ScheduleItem{
+		{Name: "bedtime", Time: "22:30", Action: "temp", Temperature: "68F"},
+		{Time: "07:00", Action: "off"},
+		{Time: "21:00", Action: "on"},
+	}
+	added, removed := diffSchedule(old, cur)
+	if len(added) != 2 || added[0].Time != "22:30" || added[1].Time != "21:00" {
+		t.Fatalf("unexpected added: %+v", added)
+	}
+	if len(removed) != 1 || removed[0].Time != "22:00" {
+		t.Fatalf("unexpected removed: %+v", removed)
+	}
+}
+
+func TestRunnerReloadsOnConfigChange(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var next []ScheduleItem
+	var nextErr error
+	clock := newFakeClock(time.Date(2024, 1, 1, 21, 58, 30, 0, loc))
+	setNext := func(items []ScheduleItem, err error, content string) {
+		clock.settle()
+		mu.Lock()
+		next, nextErr = items, err
+		mu.Unlock()
+		if err := os.WriteFile(cfg, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctrl := &fakeController{}
+	r := &Runner{
+		Items:      []ScheduleItem{{Time: "22:00", Action: "on"}},
+		Client:     ctrl,
+		Timezone:   loc,
+		Clock:      clock,
+		ConfigFile: cfg,
+		Reload: func() (ScheduleConfig, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return ScheduleConfig{Items: next}, nextErr
+		},
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute) // 21:59:30
+
+	// An invalid schedule is rejected and 22:00 still turns the bed on.
+	setNext([]ScheduleItem{{Time: "25:00", Action: "off"}}, nil, "v2-bad")
+	clock.advance(time.Minute) // 22:00:30
+
+	// A valid edit takes effect on the next tick.
+	setNext([]ScheduleItem{{Time: "22:01", Action: "off"}}, nil, "v3-good")
+	clock.advance(time.Minute) // 22:01:30
+
+	// A loader error also keeps the current schedule.
+	setNext(nil, errors.New("yaml: broken"), "v4-broken")
+	clock.advance(time.Minute)
+	stop()
+
+	got := ctrl.Calls()
+	if len(got) != 2 || got[0] != "on" || got[1] != "off" {
+		t.Fatalf("expected on then off, got %v", got)
+	}
+	if len(r.Items) != 1 || r.Items[0].Time != "22:01" {
+		t.Fatalf("expected reloaded schedule to stay in effect, got %+v", r.Items)
+	}
+}
+
+func TestReloadSwapsTimezoneAndTemps(t *testing.T) {
+	utc := mustLoc(t, "UTC")
+	ny := mustLoc(t, "America/New_York")
+	temps := tempconv.Table{{Level: -100, Fahrenheit: 60}, {Level: 100, Fahrenheit: 100}}
+	r := &Runner{
+		Items:    []ScheduleItem{{Time: "22:00", Action: "on"}},
+		Timezone: utc,
+		Reload: func() (ScheduleConfig, error) {
+			return ScheduleConfig{Items: []ScheduleItem{{Time: "22:00", Action: "temp", Temperature: "80F"}}, Timezone: ny, Temps: temps}, nil
+		},
+	}
+	if triggers := r.reload(nil, "test"); len(triggers) != 1 {
+		t.Fatalf("expected the new schedule, got %d triggers", len(triggers))
+	}
+	if r.Timezone != ny || len(r.Temps) != 2 {
+		t.Fatalf("expected timezone and temps from the reload, got %s %+v", r.Timezone, r.Temps)
+	}
+}
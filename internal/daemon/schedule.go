@@ -0,0 +1,226 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// trigger decides when a schedule item fires.
+type trigger struct {
+	hour, minute int
+	cron         *cronSpec
+	// days is a weekday bitmask (bit 0 = Sunday); 0 means every day.
+	days uint8
+	// from/until bound the civil dates on which the item may fire; zero means unbounded.
+	from, until time.Time
+	catchUp     string
+	grace       time.Duration
+	// cond makes the item fire on sleep data instead of the clock.
+	cond *condition
+}
+
+var dayNames = map[string]uint8{
+	"sun": 1 << time.Sunday, "sunday": 1 << time.Sunday,
+	"mon": 1 << time.Monday, "monday": 1 << time.Monday,
+	"tue": 1 << time.Tuesday, "tuesday": 1 << time.Tuesday,
+	"wed": 1 << time.Wednesday, "wednesday": 1 << time.Wednesday,
+	"thu": 1 << time.Thursday, "thursday": 1 << time.Thursday,
+	"fri": 1 << time.Friday, "friday": 1 << time.Friday,
+	"sat": 1 << time.Saturday, "saturday": 1 << time.Saturday,
+	"weekdays": 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday,
+	"weekends": 1<<time.Saturday | 1<<time.Sunday,
+}
+
+func parseDays(days []string) (uint8, error) {
+	var mask uint8
+	for _, d := range days {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if bits, ok := dayNames[d]; ok {
+			mask |= bits
+			continue
+		}
+		// Numeric days follow the alarm convention: 0=Sun..6=Sat.
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 0 || n > 6 {
+			return 0, fmt.Errorf("invalid day %q", d)
+		}
+		mask |= 1 << uint(n)
+	}
+	return mask, nil
+}
+
+func (s ScheduleItem) trigger(loc *time.Location) (*trigger, error) {
+	tr := &trigger{}
+	set := 0
+	for _, v := range []string{s.Time, s.Cron, s.When} {
+		if v != "" {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return nil, fmt.Errorf("set only one of time, cron or when")
+	case s.When != "":
+		cond, err := parseCondition(s.When)
+		if err != nil {
+			return nil, fmt.Errorf("when %q: %w", s.When, err)
+		}
+		tr.cond = cond
+	case s.Cron != "":
+		spec, err := parseCron(s.Cron)
+		if err != nil {
+			return nil, err
+		}
+		tr.cron = spec
+	case s.Time != "":
+		t, err := time.Parse("15:04", s.Time)
+		if err != nil {
+			return nil, fmt.Errorf("parse time %s: %w", s.Time, err)
+		}
+		tr.hour, tr.minute = t.Hour(), t.Minute()
+	default:
+		return nil, fmt.Errorf("time, cron or when is required")
+	}
+	days, err := parseDays(s.Days)
+	if err != nil {
+		return nil, err
+	}
+	tr.days = days
+	if s.From != "" {
+		if tr.from, err = time.ParseInLocation(dateLayout, s.From, loc); err != nil {
+			return nil, fmt.Errorf("parse from %s: %w", s.From, err)
+		}
+	}
+	if s.Until != "" {
+		if tr.until, err = time.ParseInLocation(dateLayout, s.Until, loc); err != nil {
+			return nil, fmt.Errorf("parse until %s: %w", s.Until, err)
+		}
+	}
+	if !tr.from.IsZero() && !tr.until.IsZero() && tr.until.Before(tr.from) {
+		return nil, fmt.Errorf("until %s is before from %s", s.Until, s.From)
+	}
+	switch s.CatchUp {
+	case "", CatchUpSkip, CatchUpLatest, CatchUpAll:
+		tr.catchUp = s.CatchUp
+	default:
+		return nil, fmt.Errorf("invalid catch_up %q (allowed: %s, %s, %s)", s.CatchUp, CatchUpSkip, CatchUpLatest, CatchUpAll)
+	}
+	if s.Grace != "" {
+		if tr.grace, err = time.ParseDuration(s.Grace); err != nil || tr.grace <= 0 {
+			return nil, fmt.Errorf("invalid grace %q", s.Grace)
+		}
+	}
+	return tr, nil
+}
+
+// allows reports whether the weekday and date-range filters admit t.
+func (tr *trigger) allows(t time.Time) bool {
+	if tr.days != 0 && tr.days&(1<<uint(t.Weekday())) == 0 {
+		return false
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if !tr.from.IsZero() && day.Before(tr.from) {
+		return false
+	}
+	if !tr.until.IsZero() && day.After(tr.until) {
+		return false
+	}
+	return true
+}
+
+// next returns the first firing strictly after t (in loc), or the zero time
+// when the item will never fire again. Condition items have no clock firings.
+func (tr *trigger) next(t time.Time, loc *time.Location) time.Time {
+	if tr.cond != nil {
+		return time.Time{}
+	}
+	t = t.In(loc)
+	if !tr.from.IsZero() && t.Before(tr.from) {
+		t = tr.from.Add(-time.Nanosecond)
+	}
+	// Each iteration advances at least one day, so this bounds the search to ~5 years.
+	for i := 0; i < 5*366; i++ {
+		var cand time.Time
+		if tr.cron != nil {
+			cand = tr.cron.next(t)
+			if cand.IsZero() {
+				return time.Time{}
+			}
+		} else {
+			cand = time.Date(t.Year(), t.Month(), t.Day(), tr.hour, tr.minute, 0, 0, loc)
+			if !cand.After(t) {
+				cand = time.Date(t.Year(), t.Month(), t.Day()+1, tr.hour, tr.minute, 0, 0, loc)
+			}
+		}
+		if !tr.until.IsZero() && !cand.Before(tr.until.AddDate(0, 0, 1)) {
+			return time.Time{}
+		}
+		if tr.allows(cand) {
+			return cand
+		}
+		// Skip the rest of a filtered-out day rather than stepping through it.
+		t = time.Date(cand.Year(), cand.Month(), cand.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	}
+	return time.Time{}
+}
+
+// Firing is a concrete occurrence of a schedule item.
+type Firing struct {
+	At    time.Time
+	Index int
+	Item  ScheduleItem
+	// Session is the sleep session a condition firing belongs to.
+	Session string
+}
+
+// ValidateSchedule checks every item's trigger and action parameters.
+func ValidateSchedule(items []ScheduleItem, loc *time.Location) error {
+	_, err := compileSchedule(items, loc)
+	return err
+}
+
+func compileSchedule(items []ScheduleItem, loc *time.Location) ([]*trigger, error) {
+	triggers := make([]*trigger, len(items))
+	for i, item := range items {
+		tr, err := item.trigger(loc)
+		if err != nil {
+			return nil, fmt.Errorf("schedule item %d (%s): %w", i+1, item.Label(), err)
+		}
+		if err := item.validateAction(); err != nil {
+			return nil, fmt.Errorf("schedule item %d (%s): %w", i+1, item.Label(), err)
+		}
+		triggers[i] = tr
+	}
+	return triggers, nil
+}
+
+// Plan lists every firing in the window (from, to], ordered by time.
+// The Runner uses the same evaluation, so this is what the daemon will do.
+func Plan(items []ScheduleItem, loc *time.Location, from, to time.Time) ([]Firing, error) {
+	triggers, err := compileSchedule(items, loc)
+	if err != nil {
+		return nil, err
+	}
+	return planCompiled(items, triggers, loc, from, to), nil
+}
+
+func planCompiled(items []ScheduleItem, triggers []*trigger, loc *time.Location, from, to time.Time) []Firing {
+	var out []Firing
+	for i, tr := range triggers {
+		for t := tr.next(from, loc); !t.IsZero() && !t.After(to); t = tr.next(t, loc) {
+			out = append(out, Firing{At: t, Index: i, Item: items[i]})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		if out[a].At.Equal(out[b].At) {
+			return out[a].Index < out[b].Index
+		}
+		return out[a].At.Before(out[b].At)
+	})
+	return out
+}
@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoc(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	return loc
+}
+
+func TestPlanDailyWithDays(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	items := []ScheduleItem{{Time: "22:00", Days: []string{"weekdays"}, Action: "on"}}
+	// 2024-01-05 is a Friday.
+	from := time.Date(2024, 1, 5, 0, 0, 0, 0, loc)
+	firings, err := Plan(items, loc, from, from.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	var got []string
+	for _, f := range firings {
+		got = append(got, f.At.Format("Mon 01-02 15:04"))
+	}
+	want := []string{"Fri 01-05 22:00", "Mon 01-08 22:00", "Tue 01-09 22:00", "Wed 01-10 22:00", "Thu 01-11 22:00"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPlanDateRange(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	items := []ScheduleItem{{Time: "07:00", From: "2024-03-10", Until: "2024-03-12", Action: "off"}}
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, loc)
+	firings, err := Plan(items, loc, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(firings) != 3 {
+		t.Fatalf("expected 3 firings, got %d", len(firings))
+	}
+	if firings[0].At.Day() != 10 || firings[2].At.Day() != 12 {
+		t.Fatalf("unexpected range firings: %v .. %v", firings[0].At, firings[2].At)
+	}
+}
+
+func TestPlanCron(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	items := []ScheduleItem{
+		{Cron: "*/30 6-7 * * mon-fri", Action: "temp", Temperature: "20"},
+		{Cron: "@daily", Action: "off"},
+	}
+	// Monday.
+	from := time.Date(2024, 1, 8, 0, 0, 0, 0, loc)
+	firings, err := Plan(items, loc, from, from.Add(24*time.Hour-time.Second))
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	// 06:00, 06:30, 07:00, 07:30; @daily's next midnight falls outside (from, to].
+	if len(firings) != 4 {
+		t.Fatalf("expected 4 firings, got %d: %v", len(firings), firings)
+	}
+	if firings[3].At.Format("15:04") != "07:30" {
+		t.Fatalf("unexpected last firing %v", firings[3].At)
+	}
+}
+
+func TestCronDayOfMonthOrWeekday(t *testing.T) {
+	spec, err := parseCron("0 9 1 * sun")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	// Fri 2024-03-01 matches day-of-month; Sun 2024-03-03 matches weekday.
+	got := spec.next(time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC))
+	if got.Day() != 1 {
+		t.Fatalf("expected Mar 1, got %v", got)
+	}
+	got = spec.next(got)
+	if got.Day() != 3 {
+		t.Fatalf("expected Mar 3, got %v", got)
+	}
+}
+
+func TestPlanAcrossDST(t *testing.T) {
+	loc := mustLoc(t, "America/New_York")
+	items := []ScheduleItem{{Time: "22:00", Action: "on"}}
+	// US DST starts 2024-03-10.
+	from := time.Date(2024, 3, 9, 12, 0, 0, 0, loc)
+	firings, err := Plan(items, loc, from, from.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(firings) != 2 {
+		t.Fatalf("expected 2 firings, got %d", len(firings))
+	}
+	for _, f := range firings {
+		if f.At.Hour() != 22 {
+			t.Fatalf("expected local 22:00, got %v", f.At)
+		}
+	}
+	if d := firings[1].At.Sub(firings[0].At); d != 23*time.Hour {
+		t.Fatalf("expected 23h between firings across DST, got %v", d)
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	bad := [][]ScheduleItem{
+		{{Action: "on"}},
+		{{Time: "22:00", Cron: "* * * * *", Action: "on"}},
+		{{Cron: "61 * * * *", Action: "on"}},
+		{{Time: "22:00", Days: []string{"funday"}, Action: "on"}},
+		{{Time: "22:00", From: "2024-02-01", Until: "2024-01-01", Action: "on"}},
+		{{Time: "22:00", Action: "dance"}},
+		{{Time: "22:00", Action: "temp"}},
+	}
+	for i, items := range bad {
+		if err := ValidateSchedule(items, loc); err == nil {
+			t.Errorf("case %d: expected validation error", i)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Service managers supported by daemon install.
+const (
+	ServiceSystemd = "systemd"
+	ServiceLaunchd = "launchd"
+)
+
+// Service names used for the generated files.
+const (
+	SystemdUnitName = "eightsleep-daemon.service"
+	LaunchdLabel    = "com.salmonumbrella.eightsleep.daemon"
+)
+
+// ServiceSpec describes how a service manager should start the daemon.
+type ServiceSpec struct {
+	// Args is the full command line, starting with the executable path.
+	Args []string
+	// LogFile receives stdout and stderr under launchd; systemd uses the journal.
+	LogFile string
+}
+
+// ServiceFile returns where a user-level service file lives under home.
+func ServiceFile(manager, home string) (string, error) {
+	switch manager {
+	case ServiceSystemd:
+		return filepath.Join(home, ".config", "systemd", "user", SystemdUnitName), nil
+	case ServiceLaunchd:
+		return filepath.Join(home, "Library", "LaunchAgents", LaunchdLabel+".plist"), nil
+	default:
+		return "", fmt.Errorf("unknown service manager %q (allowed: %s, %s)", manager, ServiceSystemd, ServiceLaunchd)
+	}
+}
+
+// RenderService renders the service file for manager.
+func RenderService(manager string, spec ServiceSpec) (string, error) {
+	if len(spec.Args) == 0 {
+		return "", fmt.Errorf("service needs a command to run")
+	}
+	var tmpl *template.Template
+	switch manager {
+	case ServiceSystemd:
+		tmpl = systemdTemplate
+	case ServiceLaunchd:
+		tmpl = launchdTemplate
+	default:
+		return "", fmt.Errorf("unknown service manager %q (allowed: %s, %s)", manager, ServiceSystemd, ServiceLaunchd)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, spec); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+var systemdTemplate = template.Must(template.New("systemd").Funcs(template.FuncMap{
+	"exec": systemdExec,
+}).Parse(`[Unit]
+Description=Eight Sleep schedule daemon
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart={{exec .Args}}
+ExecReload=/bin/kill -HUP $MAINPID
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`))
+
+var launchdTemplate = template.Must(template.New("launchd").Funcs(template.FuncMap{
+	"xml": xmlEscape,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + LaunchdLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args}}
+		<string>{{xml .}}</string>
+{{- end}}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+{{- if .LogFile}}
+	<key>StandardOutPath</key>
+	<string>{{xml .LogFile}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{xml .LogFile}}</string>
+{{- end}}
+</dict>
+</plist>
+`))
+
+// systemdExec quotes a command line for ExecStart: every argument is
+// double-quoted, and specifiers (%) and variables ($) are escaped so paths are
+// taken literally.
+func systemdExec(args []string) string {
+	quoted := make([]string, len(args))
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$")
+	for i, a := range args {
+		quoted[i] = `"` + r.Replace(a) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+func xmlEscape(s string) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
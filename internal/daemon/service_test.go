@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestRenderSystemdUnit(t *testing.T) {
+	out, err := RenderService(ServiceSystemd, ServiceSpec{
+		Args: []string{"/opt/eight sleep/eightsleep", "daemon", "--config", `/home/me/100%"cfg"$HOME.yaml`},
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := `ExecStart="/opt/eight sleep/eightsleep" "daemon" "--config" "/home/me/100%%\"cfg\"$$HOME.yaml"` + "\n"
+	if !strings.Contains(out, want) {
+		t.Fatalf("expected %q in:\n%s", want, out)
+	}
+	for _, line := range []string{"[Service]", "ExecReload=/bin/kill -HUP $MAINPID", "Restart=on-failure", "WantedBy=default.target"} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("missing %q", line)
+		}
+	}
+}
+
+func TestRenderLaunchdPlist(t *testing.T) {
+	out, err := RenderService(ServiceLaunchd, ServiceSpec{
+		Args:    []string{"/usr/local/bin/eightsleep", "daemon", "--account", "me&<you>"},
+		LogFile: "/Users/me/Library/Logs/eightsleep-daemon.log",
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(out, "<string>me&amp;&lt;you&gt;</string>") {
+		t.Fatalf("expected escaped argument in:\n%s", out)
+	}
+	// The plist must be well-formed XML.
+	var doc struct {
+		Dict struct {
+			Keys   []string `xml:"key"`
+			Values []string `xml:"string"`
+			Array  []string `xml:"array>string"`
+		} `xml:"dict"`
+	}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("parse plist: %v", err)
+	}
+	if len(doc.Dict.Array) != 4 || doc.Dict.Array[3] != "me&<you>" || doc.Dict.Values[0] != LaunchdLabel {
+		t.Fatalf("unexpected plist contents: %+v", doc.Dict)
+	}
+}
+
+func TestServiceFile(t *testing.T) {
+	if got, _ := ServiceFile(ServiceSystemd, "/home/me"); got != "/home/me/.config/systemd/user/eightsleep-daemon.service" {
+		t.Fatalf("unexpected systemd path %s", got)
+	}
+	if got, _ := ServiceFile(ServiceLaunchd, "/Users/me"); got != "/Users/me/Library/LaunchAgents/"+LaunchdLabel+".plist" {
+		t.Fatalf("unexpected launchd path %s", got)
+	}
+	if _, err := ServiceFile("upstart", "/home/me"); err == nil {
+		t.Fatalf("expected unknown manager error")
+	}
+	if _, err := RenderService(ServiceSystemd, ServiceSpec{}); err == nil {
+		t.Fatalf("expected error without a command")
+	}
+}
@@ -0,0 +1,132 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// DefaultSyncInterval is how often --sync-state compares the device with the schedule.
+const DefaultSyncInterval = 15 * time.Minute
+
+// syncLookback bounds how far back the schedule is searched for the firing
+// currently in effect; it covers daily and weekly items.
+const syncLookback = 8 * 24 * time.Hour
+
+// Journal results for state reconciliation.
+const (
+	ResultReconciled = "reconciled"
+	ResultDrift      = "drift"
+)
+
+// desiredState is what the schedule says should be in effect at a moment.
+// Power and Level are nil when no recent firing decides them.
+type desiredState struct {
+	Power      *Firing
+	Level      *Firing
+	LevelValue int
+}
+
+// desired finds the latest on/off and temp firings at or before now.
+func (r *Runner) desired(triggers []*trigger, now time.Time) (desiredState, error) {
+	var st desiredState
+	for _, f := range planCompiled(r.Items, triggers, r.Timezone, now.Add(-syncLookback), now) {
+		switch f.Item.Action {
+		case "on", "off":
+			st.Power = &f
+		case "temp":
+			level, err := r.temps().Parse(f.Item.Temperature)
+			if err != nil {
+				return st, err
+			}
+			d, err := f.Item.duration()
+			if err != nil {
+				return st, err
+			}
+			if d > 0 && !now.Before(f.At.Add(d)) {
+				// A timed setting that has run out no longer decides the level.
+				continue
+			}
+			st.Level, st.LevelValue = &f, level
+		}
+	}
+	return st, nil
+}
+
+// reconcile reads the device state and re-applies whatever the schedule says
+// should be in effect if it has drifted (e.g. changed in the app). In dry-run
+// mode drift is only reported.
+func (r *Runner) reconcile(ctx context.Context, triggers []*trigger, now time.Time) error {
+	want, err := r.desired(triggers, now)
+	if err != nil {
+		return err
+	}
+	if want.Power == nil && want.Level == nil {
+		return nil
+	}
+	status, err := r.Client.GetStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("read device state: %w", err)
+	}
+	r.Metrics.setBedLevel(status.CurrentLevel)
+	isOn := status.CurrentState.Type != "off"
+	if want.Power != nil {
+		wantOn := want.Power.Item.Action == "on"
+		if wantOn != isOn {
+			r.fixDrift(ctx, *want.Power, fmt.Sprintf("power is %s, schedule says %s", onOff(isOn), want.Power.Item.Action), func() error {
+				if wantOn {
+					return r.Client.TurnOn(ctx)
+				}
+				return r.Client.TurnOff(ctx)
+			})
+			isOn = wantOn
+		}
+	}
+	// A level only matters while the bed is on; turning it on just to fix
+	// the level would override an explicit off.
+	if want.Level != nil && isOn && status.CurrentLevel != want.LevelValue {
+		f := *want.Level
+		r.fixDrift(ctx, f, fmt.Sprintf("level is %d, schedule says %d", status.CurrentLevel, want.LevelValue), func() error {
+			var remaining time.Duration
+			if d, _ := f.Item.duration(); d > 0 {
+				// Re-apply only for what is left of the timed setting.
+				remaining = f.At.Add(d).Sub(now)
+			}
+			return r.Client.SetLevel(ctx, want.LevelValue, int(remaining/time.Second))
+		})
+	}
+	return nil
+}
+
+func (r *Runner) fixDrift(ctx context.Context, f Firing, drift string, apply func() error) {
+	if r.DryRun {
+		fmt.Printf("DRY-RUN DRIFT %s: %s (since %s)\n", f.Item.Label(), drift, f.At.Format(time.RFC3339))
+		r.record(f, ResultDrift, 0, nil)
+		return
+	}
+	if err := apply(); err != nil {
+		if ctx.Err() == nil {
+			log.Error("reconcile failed", "item", f.Item.Label(), "drift", drift, "error", err)
+		}
+		r.record(f, ResultError, 1, err)
+		return
+	}
+	log.Info("reconciled device state", "item", f.Item.Label(), "drift", drift, "scheduled", f.At.Format(time.RFC3339))
+	r.record(f, ResultReconciled, 1, nil)
+}
+
+func (r *Runner) syncInterval() time.Duration {
+	if r.SyncInterval <= 0 {
+		return DefaultSyncInterval
+	}
+	return r.SyncInterval
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
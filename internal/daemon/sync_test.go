@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunnerSyncReappliesDrift(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 22, 10, 0, 0, loc))
+	ctrl := &fakeController{}
+	ctrl.set(true, 0)
+	r := &Runner{
+		Items: []ScheduleItem{
+			{Time: "22:00", Action: "on"},
+			{Time: "22:00", Action: "temp", Temperature: "-20"},
+		},
+		Client:       ctrl,
+		Timezone:     loc,
+		Clock:        clock,
+		Sync:         true,
+		SyncInterval: 10 * time.Minute,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute) // 22:11 first sync
+	clock.settle()
+	ctrl.set(false, 30)            // changed in the app
+	clock.advance(5 * time.Minute) // 22:16, inside the interval
+	clock.advance(5 * time.Minute) // 22:21, next sync
+	clock.settle()
+	stop()
+	want := []string{"on", "temp -20", "temp -20"}
+	if got := ctrl.Calls(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRunnerSyncLeavesLevelWhenScheduledOff(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 2, 8, 0, 0, 0, loc))
+	ctrl := &fakeController{}
+	ctrl.set(false, 5)
+	r := &Runner{
+		Items: []ScheduleItem{
+			{Time: "22:00", Action: "temp", Temperature: "-20"},
+			{Time: "07:00", Action: "off"},
+		},
+		Client:   ctrl,
+		Timezone: loc,
+		Clock:    clock,
+		Sync:     true,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute)
+	clock.settle()
+	stop()
+	if got := ctrl.Calls(); len(got) != 1 || got[0] != "off" {
+		t.Fatalf("expected only off, got %v", got)
+	}
+}
+
+func TestRunnerSyncDryRunReportsDrift(t *testing.T) {
+	loc := mustLoc(t, "UTC")
+	clock := newFakeClock(time.Date(2024, 1, 1, 22, 10, 0, 0, loc))
+	ctrl := &fakeController{}
+	ctrl.set(false, 10)
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	r := &Runner{
+		Items:    []ScheduleItem{{Name: "bedtime", Time: "22:00", Action: "temp", Temperature: "-20"}},
+		Client:   ctrl,
+		Timezone: loc,
+		Clock:    clock,
+		DryRun:   true,
+		Sync:     true,
+		Journal:  journal,
+	}
+	stop := runWithClock(t, r)
+	clock.advance(time.Minute)
+	clock.settle()
+	stop()
+	if got := ctrl.Calls(); len(got) != 0 {
+		t.Fatalf("dry-run changed the device: %v", got)
+	}
+	entries, err := journal.Read(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Result != ResultDrift || entries[0].Item != "bedtime" {
+		t.Fatalf("expected one drift entry, got %+v", entries)
+	}
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Formatter renders a raw cell value for people. Values it does not
+// understand, including nil, are returned unchanged.
+type Formatter func(v any) any
+
+// Columns maps header names to the formatter for that column.
+type Columns map[string]Formatter
+
+// PrintColumns is Print with typed columns: formatters apply in table mode,
+// and in JSON and CSV only when human is set, so scripts keep raw values.
+func PrintColumns(format Format, headers []string, rows []map[string]any, cols Columns, human bool) error {
+	if Humanized(format, human) {
+		rows = Humanize(rows, cols)
+	}
+	return Print(format, headers, rows)
+}
+
+// Humanized reports whether PrintColumns formats values for format.
+func Humanized(format Format, human bool) bool {
+	return human || (format != FormatJSON && format != FormatCSV)
+}
+
+// Humanize returns copies of rows with each column passed through its formatter.
+func Humanize(rows []map[string]any, cols Columns) []map[string]any {
+	if len(cols) == 0 {
+		return rows
+	}
+	out := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		m := make(map[string]any, len(row))
+		for k, v := range row {
+			if f, ok := cols[k]; ok {
+				v = f(v)
+			}
+			m[k] = v
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// Duration renders seconds as "7h 32m", "45m" or "30s".
+func Duration(v any) any {
+	secs, ok := number(v)
+	if !ok {
+		return v
+	}
+	sign := ""
+	if secs < 0 {
+		sign, secs = "-", -secs
+	}
+	// Round first so 59.6s reads as "1m", not "60s".
+	secs = math.Round(secs)
+	if secs < 60 {
+		return fmt.Sprintf("%s%ds", sign, int(secs))
+	}
+	mins := int(math.Round(secs / 60))
+	if mins < 60 {
+		return fmt.Sprintf("%s%dm", sign, mins)
+	}
+	return fmt.Sprintf("%s%dh %dm", sign, mins/60, mins%60)
+}
+
+// Percent renders a percentage value as "46.7%".
+func Percent(v any) any {
+	p, ok := number(v)
+	if !ok {
+		return v
+	}
+	return strconv.FormatFloat(math.Round(p*10)/10, 'f', -1, 64) + "%"
+}
+
+// Timestamp renders RFC 3339 strings and times as "2006-01-02 15:04" in loc.
+func Timestamp(loc *time.Location) Formatter {
+	return func(v any) any {
+		var t time.Time
+		switch x := v.(type) {
+		case time.Time:
+			t = x
+		case string:
+			parsed, err := time.Parse(time.RFC3339, x)
+			if err != nil {
+				return v
+			}
+			t = parsed
+		default:
+			return v
+		}
+		return t.In(loc).Format("2006-01-02 15:04")
+	}
+}
+
+func number(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	default:
+		return 0, false
+	}
+}
@@ -44,7 +44,7 @@ func Print(format Format, headers []string, rows []map[string]any) error {
 		for _, row := range rows {
 			line := make([]string, len(headers))
 			for i, h := range headers {
-				line[i] = fmt.Sprint(row[h])
+				line[i] = cell(row[h])
 			}
 			if err := w.Write(line); err != nil {
 				return err
@@ -58,7 +58,7 @@ func Print(format Format, headers []string, rows []map[string]any) error {
 		for _, row := range rows {
 			vals := make([]string, len(headers))
 			for i, h := range headers {
-				vals[i] = fmt.Sprint(row[h])
+				vals[i] = cell(row[h])
 			}
 			_, _ = fmt.Fprintln(w, strings.Join(vals, "\t"))
 		}
@@ -66,6 +66,14 @@ func Print(format Format, headers []string, rows []map[string]any) error {
 	}
 }
 
+// cell renders a value for table and CSV output; nil and absent keys are blank.
+func cell(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
 // FilterFields trims rows to selected fields; if fields empty, return rows.
 func FilterFields(rows []map[string]any, fields []string) []map[string]any {
 	if len(fields) == 0 {
@@ -1,6 +1,9 @@
 package output
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestFilterFields(t *testing.T) {
 	rows := []map[string]any{{"a": 1, "b": 2}, {"a": 3, "b": 4}}
@@ -15,3 +18,60 @@ func TestFilterFields(t *testing.T) {
 		t.Fatalf("unexpected values: %+v", got)
 	}
 }
+
+func TestDuration(t *testing.T) {
+	for _, tc := range []struct {
+		in   any
+		want any
+	}{
+		{27120.0, "7h 32m"},
+		{28800, "8h 0m"},
+		{2700.0, "45m"},
+		{30.0, "30s"},
+		{59.4, "59s"},
+		{59.6, "1m"},
+		{-300.0, "-5m"},
+		{nil, nil},
+		{"n/a", "n/a"},
+	} {
+		if got := Duration(tc.in); got != tc.want {
+			t.Errorf("Duration(%v) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestPercentAndTimestamp(t *testing.T) {
+	if got := Percent(46.66); got != "46.7%" {
+		t.Fatalf("Percent = %v", got)
+	}
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	if got := Timestamp(loc)("2024-01-02T03:30:00Z"); got != "2024-01-01 22:30" {
+		t.Fatalf("Timestamp = %v", got)
+	}
+	if got := Timestamp(loc)("yesterday"); got != "yesterday" {
+		t.Fatalf("expected unparseable value unchanged, got %v", got)
+	}
+}
+
+func TestHumanizeCopiesRows(t *testing.T) {
+	rows := []map[string]any{{"duration": 3600.0, "score": 90}}
+	got := Humanize(rows, Columns{"duration": Duration})
+	if got[0]["duration"] != "1h 0m" || got[0]["score"] != 90 {
+		t.Fatalf("unexpected rows: %v", got)
+	}
+	if rows[0]["duration"] != 3600.0 {
+		t.Fatalf("input rows were modified: %v", rows)
+	}
+}
+
+func TestCellBlanksNil(t *testing.T) {
+	if got := cell(nil); got != "" {
+		t.Fatalf("cell(nil) = %q, want empty", got)
+	}
+	if got := cell(90); got != "90" {
+		t.Fatalf("cell(90) = %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Summary describes a sample of values.
+type Summary struct {
+	N      int
+	Mean   float64
+	Median float64
+	Min    float64
+	Max    float64
+	// StdDev is the sample standard deviation; zero when N < 2.
+	StdDev float64
+}
+
+// Summarize computes the summary of values; an empty input yields N == 0.
+func Summarize(values []float64) Summary {
+	n := len(values)
+	if n == 0 {
+		return Summary{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	s := Summary{N: n, Min: sorted[0], Max: sorted[n-1]}
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	s.Mean = sum / float64(n)
+	if n%2 == 1 {
+		s.Median = sorted[n/2]
+	} else {
+		s.Median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	if n > 1 {
+		var ss float64
+		for _, v := range sorted {
+			ss += (v - s.Mean) * (v - s.Mean)
+		}
+		s.StdDev = math.Sqrt(ss / float64(n-1))
+	}
+	return s
+}
+
+// TTest is the result of Welch's two-sample t-test.
+type TTest struct {
+	T  float64
+	DF float64
+	// P is the two-sided p-value.
+	P float64
+}
+
+// Welch tests whether a and b have different means without assuming equal
+// variances. ok is false when either sample has fewer than two values or both
+// are constant.
+func Welch(a, b []float64) (TTest, bool) {
+	sa, sb := Summarize(a), Summarize(b)
+	if sa.N < 2 || sb.N < 2 {
+		return TTest{}, false
+	}
+	va := sa.StdDev * sa.StdDev / float64(sa.N)
+	vb := sb.StdDev * sb.StdDev / float64(sb.N)
+	if va+vb == 0 {
+		return TTest{}, false
+	}
+	t := (sa.Mean - sb.Mean) / math.Sqrt(va+vb)
+	df := (va + vb) * (va + vb) / (va*va/float64(sa.N-1) + vb*vb/float64(sb.N-1))
+	return TTest{T: t, DF: df, P: incompleteBeta(df/2, 0.5, df/(df+t*t))}, true
+}
+
+// Correlation is Pearson's r between paired samples.
+type Correlation struct {
+	N int
+	R float64
+	// P is the two-sided p-value for r != 0.
+	P float64
+}
+
+// Pearson correlates x with y. ok is false when the lengths differ, there are
+// fewer than three pairs, or either side is constant.
+func Pearson(x, y []float64) (Correlation, bool) {
+	n := len(x)
+	if n != len(y) || n < 3 {
+		return Correlation{}, false
+	}
+	mx, my := Summarize(x).Mean, Summarize(y).Mean
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return Correlation{}, false
+	}
+	r := sxy / math.Sqrt(sxx*syy)
+	c := Correlation{N: n, R: r}
+	if math.Abs(r) >= 1 {
+		return c, true
+	}
+	df := float64(n - 2)
+	t := r * math.Sqrt(df/(1-r*r))
+	c.P = incompleteBeta(df/2, 0.5, df/(df+t*t))
+	return c, true
+}
+
+// incompleteBeta is the regularized incomplete beta function I_x(a, b).
+func incompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a + b)
+	lb, _ := math.Lgamma(a)
+	lc, _ := math.Lgamma(b)
+	front := math.Exp(la - lb - lc + a*math.Log(x) + b*math.Log(1-x))
+	// The continued fraction converges fastest below the mean of the distribution.
+	if x < (a+1)/(a+b+2) {
+		return front * betaFraction(a, b, x) / a
+	}
+	return 1 - front*betaFraction(b, a, 1-x)/b
+}
+
+// betaFraction evaluates the continued fraction for incompleteBeta with the
+// modified Lentz method.
+func betaFraction(a, b, x float64) float64 {
+	const (
+		maxIter = 200
+		eps     = 1e-14
+		tiny    = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIter; m++ {
+		fm := float64(m)
+		for _, num := range []float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			d = 1 + num*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + num/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			h *= d * c
+		}
+		if math.Abs(d*c-1) < eps {
+			break
+		}
+	}
+	return h
+}
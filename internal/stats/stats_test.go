@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]float64{4, 1, 3, 2})
+	if s.N != 4 || s.Mean != 2.5 || s.Median != 2.5 || s.Min != 1 || s.Max != 4 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+	if math.Abs(s.StdDev-1.2909944) > 1e-6 {
+		t.Fatalf("StdDev = %v, want ~1.291", s.StdDev)
+	}
+	if odd := Summarize([]float64{5, 1, 3}); odd.Median != 3 {
+		t.Fatalf("odd median = %v, want 3", odd.Median)
+	}
+}
+
+func TestSummarizeSmallSamples(t *testing.T) {
+	if s := Summarize(nil); s.N != 0 {
+		t.Fatalf("expected empty summary, got %+v", s)
+	}
+	if s := Summarize([]float64{7}); s.StdDev != 0 || s.Mean != 7 || s.Median != 7 {
+		t.Fatalf("unexpected single-value summary: %+v", s)
+	}
+}
+
+func TestWelch(t *testing.T) {
+	a := []float64{27.5, 21.0, 19.0, 23.6, 17.0, 17.9, 16.9, 20.1, 21.9, 22.6, 23.1, 19.6, 19.0, 21.7, 21.4}
+	b := []float64{27.1, 22.0, 20.8, 23.4, 23.4, 23.5, 25.8, 22.0, 24.8, 20.2, 21.9, 22.1, 22.9, 20.5, 24.4}
+	res, ok := Welch(a, b)
+	if !ok {
+		t.Fatalf("expected a result")
+	}
+	// Welch's worked example: t ≈ -2.455, df ≈ 24.99, p ≈ 0.0214.
+	if math.Abs(res.T+2.45536) > 1e-4 || math.Abs(res.DF-24.9885) > 1e-3 || math.Abs(res.P-0.021378) > 1e-5 {
+		t.Fatalf("unexpected t-test: %+v", res)
+	}
+	if _, ok := Welch([]float64{1}, b); ok {
+		t.Fatalf("expected no result for a single value")
+	}
+	if _, ok := Welch([]float64{2, 2}, []float64{2, 2}); ok {
+		t.Fatalf("expected no result for constant samples")
+	}
+}
+
+func TestIncompleteBetaSymmetric(t *testing.T) {
+	if got := incompleteBeta(2, 2, 0.5); math.Abs(got-0.5) > 1e-12 {
+		t.Fatalf("I_0.5(2,2) = %v, want 0.5", got)
+	}
+	if got := incompleteBeta(1, 1, 0.3); math.Abs(got-0.3) > 1e-12 {
+		t.Fatalf("I_0.3(1,1) = %v, want 0.3", got)
+	}
+}
+
+func TestPearson(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{2, 4, 5, 4, 5}
+	c, ok := Pearson(x, y)
+	if !ok {
+		t.Fatalf("expected a result")
+	}
+	if math.Abs(c.R-0.774597) > 1e-6 || math.Abs(c.P-0.124027) > 1e-5 {
+		t.Fatalf("unexpected correlation: %+v", c)
+	}
+	if c, ok := Pearson(x, []float64{5, 4, 3, 2, 1}); !ok || c.R != -1 || c.P != 0 {
+		t.Fatalf("expected perfect negative correlation, got %+v", c)
+	}
+	if _, ok := Pearson(x, []float64{3, 3, 3, 3, 3}); ok {
+		t.Fatalf("expected no result for constant y")
+	}
+}
@@ -0,0 +1,189 @@
+package tempconv
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Unit is a display unit for temperatures.
+type Unit string
+
+const (
+	Fahrenheit Unit = "F"
+	Celsius    Unit = "C"
+)
+
+// ParseUnit accepts F/C in either case, or the spelled-out names.
+func ParseUnit(s string) (Unit, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "f", "fahrenheit":
+		return Fahrenheit, nil
+	case "c", "celsius":
+		return Celsius, nil
+	default:
+		return "", fmt.Errorf("invalid temperature unit %q (allowed: F, C)", s)
+	}
+}
+
+// Point pins a heating level to the temperature (°F) the pod targets at that level.
+type Point struct {
+	Level      int     `mapstructure:"level" yaml:"level"`
+	Fahrenheit float64 `mapstructure:"fahrenheit" yaml:"fahrenheit"`
+}
+
+// Table is a piecewise-linear calibration between heating levels and °F.
+// Points must be sorted by level with strictly increasing temperatures.
+type Table []Point
+
+// DefaultTable approximates the curve shown in the Eight Sleep app: cooling
+// flattens out near the bottom of the range and heating steepens near the top,
+// with level 0 sitting around 81°F (27°C).
+var DefaultTable = Table{
+	{Level: -100, Fahrenheit: 55},
+	{Level: -80, Fahrenheit: 60},
+	{Level: -60, Fahrenheit: 64},
+	{Level: -40, Fahrenheit: 68},
+	{Level: -20, Fahrenheit: 75},
+	{Level: 0, Fahrenheit: 81},
+	{Level: 20, Fahrenheit: 86},
+	{Level: 40, Fahrenheit: 90},
+	{Level: 60, Fahrenheit: 95},
+	{Level: 80, Fahrenheit: 102},
+	{Level: 100, Fahrenheit: 110},
+}
+
+// Validate checks that the table can be interpolated in both directions.
+func (t Table) Validate() error {
+	if len(t) < 2 {
+		return fmt.Errorf("calibration table needs at least 2 points")
+	}
+	for i, p := range t {
+		if p.Level < -100 || p.Level > 100 {
+			return fmt.Errorf("calibration level %d out of range -100..100", p.Level)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := t[i-1]
+		if p.Level <= prev.Level {
+			return fmt.Errorf("calibration levels must be strictly increasing (%d after %d)", p.Level, prev.Level)
+		}
+		if p.Fahrenheit <= prev.Fahrenheit {
+			return fmt.Errorf("calibration temperatures must be strictly increasing (%.1f after %.1f)", p.Fahrenheit, prev.Fahrenheit)
+		}
+	}
+	return nil
+}
+
+// ToFahrenheit converts a heating level to °F.
+func (t Table) ToFahrenheit(level int) float64 {
+	l := float64(clampLevel(level))
+	if l <= float64(t[0].Level) {
+		return t[0].Fahrenheit
+	}
+	last := t[len(t)-1]
+	if l >= float64(last.Level) {
+		return last.Fahrenheit
+	}
+	i := sort.Search(len(t), func(i int) bool { return float64(t[i].Level) >= l })
+	lo, hi := t[i-1], t[i]
+	return lerp(l, float64(lo.Level), float64(hi.Level), lo.Fahrenheit, hi.Fahrenheit)
+}
+
+// ToCelsius converts a heating level to °C.
+func (t Table) ToCelsius(level int) float64 {
+	return FToC(t.ToFahrenheit(level))
+}
+
+// ToUnit converts a heating level to the given unit.
+func (t Table) ToUnit(level int, unit Unit) float64 {
+	if unit == Celsius {
+		return t.ToCelsius(level)
+	}
+	return t.ToFahrenheit(level)
+}
+
+// FromFahrenheit converts °F to the nearest heating level, clamped to the table.
+func (t Table) FromFahrenheit(f float64) int {
+	if f <= t[0].Fahrenheit {
+		return t[0].Level
+	}
+	last := t[len(t)-1]
+	if f >= last.Fahrenheit {
+		return last.Level
+	}
+	i := sort.Search(len(t), func(i int) bool { return t[i].Fahrenheit >= f })
+	lo, hi := t[i-1], t[i]
+	return int(math.Round(lerp(f, lo.Fahrenheit, hi.Fahrenheit, float64(lo.Level), float64(hi.Level))))
+}
+
+// FromCelsius converts °C to the nearest heating level, clamped to the table.
+func (t Table) FromCelsius(c float64) int {
+	return t.FromFahrenheit(CToF(c))
+}
+
+// Parse converts "68F", "20C" or a raw level ("-30") to a heating level.
+func (t Table) Parse(s string) (int, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(strings.ToUpper(s)), "°", "")
+	switch {
+	case strings.HasSuffix(s, "F"):
+		f, err := parseNumber(strings.TrimSuffix(s, "F"))
+		if err != nil {
+			return 0, err
+		}
+		return t.FromFahrenheit(f), nil
+	case strings.HasSuffix(s, "C"):
+		c, err := parseNumber(strings.TrimSuffix(s, "C"))
+		if err != nil {
+			return 0, err
+		}
+		return t.FromCelsius(c), nil
+	}
+	lvl, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("temperature must end with F/C or be level")
+	}
+	if lvl < -100 || lvl > 100 {
+		return 0, fmt.Errorf("level must be between -100 and 100")
+	}
+	return lvl, nil
+}
+
+// FToC converts Fahrenheit to Celsius.
+func FToC(f float64) float64 { return (f - 32) * 5 / 9 }
+
+// CToF converts Celsius to Fahrenheit.
+func CToF(c float64) float64 { return c*9/5 + 32 }
+
+// Round1 rounds to one decimal place for display.
+func Round1(v float64) float64 { return math.Round(v*10) / 10 }
+
+// Format renders a temperature already expressed in unit, e.g. "68.5°F".
+func Format(v float64, unit Unit) string {
+	return strconv.FormatFloat(Round1(v), 'f', -1, 64) + "°" + string(unit)
+}
+
+func parseNumber(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature %q", s)
+	}
+	return v, nil
+}
+
+func lerp(x, x0, x1, y0, y1 float64) float64 {
+	return y0 + (x-x0)*(y1-y0)/(x1-x0)
+}
+
+func clampLevel(level int) int {
+	if level < -100 {
+		return -100
+	}
+	if level > 100 {
+		return 100
+	}
+	return level
+}
@@ -0,0 +1,93 @@
+package tempconv
+
+import "testing"
+
+func TestDefaultTableValid(t *testing.T) {
+	if err := DefaultTable.Validate(); err != nil {
+		t.Fatalf("default table invalid: %v", err)
+	}
+}
+
+func TestTableRoundTrip(t *testing.T) {
+	for _, p := range DefaultTable {
+		if got := DefaultTable.ToFahrenheit(p.Level); got != p.Fahrenheit {
+			t.Errorf("ToFahrenheit(%d) = %v, want %v", p.Level, got, p.Fahrenheit)
+		}
+		if got := DefaultTable.FromFahrenheit(p.Fahrenheit); got != p.Level {
+			t.Errorf("FromFahrenheit(%v) = %d, want %d", p.Fahrenheit, got, p.Level)
+		}
+	}
+	for level := -100; level <= 100; level++ {
+		if got := DefaultTable.FromFahrenheit(DefaultTable.ToFahrenheit(level)); got != level {
+			t.Errorf("level %d round-tripped to %d", level, got)
+		}
+	}
+}
+
+func TestTableInterpolatesAndClamps(t *testing.T) {
+	if got := DefaultTable.ToFahrenheit(-90); got != 57.5 {
+		t.Errorf("ToFahrenheit(-90) = %v, want 57.5", got)
+	}
+	if got := DefaultTable.FromFahrenheit(40); got != -100 {
+		t.Errorf("FromFahrenheit(40) = %d, want -100", got)
+	}
+	if got := DefaultTable.FromFahrenheit(120); got != 100 {
+		t.Errorf("FromFahrenheit(120) = %d, want 100", got)
+	}
+	if got := DefaultTable.ToFahrenheit(150); got != 110 {
+		t.Errorf("ToFahrenheit(150) = %v, want 110", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"68F", -40, false},
+		{"68°f", -40, false},
+		{"27.2C", 0, false},
+		{"-30", -30, false},
+		{"101", 0, true},
+		{"warm", 0, true},
+		{"xF", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := DefaultTable.Parse(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsNonMonotonic(t *testing.T) {
+	tables := map[string]Table{
+		"too short":       {{Level: 0, Fahrenheit: 80}},
+		"levels unsorted": {{Level: 0, Fahrenheit: 80}, {Level: -10, Fahrenheit: 85}},
+		"temps decrease":  {{Level: -10, Fahrenheit: 80}, {Level: 10, Fahrenheit: 70}},
+		"level range":     {{Level: -120, Fahrenheit: 50}, {Level: 0, Fahrenheit: 80}},
+	}
+	for name, table := range tables {
+		if err := table.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := Format(68.04, Fahrenheit); got != "68°F" {
+		t.Errorf("Format = %q, want 68°F", got)
+	}
+	if got := Format(DefaultTable.ToCelsius(0), Celsius); got != "27.2°C" {
+		t.Errorf("Format = %q, want 27.2°C", got)
+	}
+	if _, err := ParseUnit("kelvin"); err == nil {
+		t.Errorf("expected invalid unit error")
+	}
+}